@@ -28,6 +28,15 @@ const (
 	CodeUserNotExist        string = "用户不存在"
 	CodeErrKafkaNotEnabled  string = "kafka未启动"
 	CodeKafkaSendFailed     string = "kafka发送失败"
+	CodeVoteTimeExpire      string = "投票时间已过"
+	CodeTaskRunning         string = "任务正在执行中"
+	CodeNoBookmarkFolder    string = "此收藏夹不存在"
+	CodeBookmarkFolderExist string = "收藏夹名称已存在"
+	CodeInvalidAttachment   string = "附件不存在或已过期"
+	CodePostNotPublished    string = "帖子尚未发布"
+	CodePostPublished       string = "帖子已发布"
+	CodePinLimit            string = "置顶帖子数量已达上限"
+	CodeNoDeadLetter        string = "此死信消息不存在"
 )
 
 // Context keys
@@ -25,4 +25,14 @@ var (
 	ErrorUserNotExist        = errors.New(CodeUserNotExist)
 	ErrKafkaNotEnabled       = errors.New(CodeErrKafkaNotEnabled)
 	ErrorInvalidCredentials  = errors.New(CodeInvalidCredentials)
+	ErrorVoteTimeExpire      = errors.New(CodeVoteTimeExpire)
+	ErrorTaskRunning         = errors.New(CodeTaskRunning)
+	ErrorNoBookmarkFolder    = errors.New(CodeNoBookmarkFolder)
+	ErrorBookmarkFolderExist = errors.New(CodeBookmarkFolderExist)
+	ErrorInvalidParam        = errors.New(CodeInvalidParam)
+	ErrorInvalidAttachment   = errors.New(CodeInvalidAttachment)
+	ErrorPostNotPublished    = errors.New(CodePostNotPublished)
+	ErrorPostPublished       = errors.New(CodePostPublished)
+	ErrorPinLimit            = errors.New(CodePinLimit)
+	ErrorNoDeadLetter        = errors.New(CodeNoDeadLetter)
 )
@@ -4,3 +4,16 @@ const (
 	OrderTime  = "time"
 	OrderScore = "score"
 )
+
+// 帖子置顶范围
+const (
+	PinScopeCommunity = "community"
+	PinScopeGlobal    = "global"
+)
+
+// 异步发帖状态
+const (
+	PostCreationPending = "pending" // 消息已发送，等待消费者写入
+	PostCreationCreated = "created" // 帖子已写入
+	PostCreationFailed  = "failed"  // 写入失败
+)
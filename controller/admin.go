@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/logic"
+	"vision/models/request"
+)
+
+// 管理员模块
+
+// RebuildRedisHandler 根据数据库重建 redis 中的排序和计数数据
+func RebuildRedisHandler(c *gin.Context) {
+	req := new(request.RebuildRedisRequest)
+	if err := c.ShouldBindQuery(req); err != nil {
+		zap.L().Error("参数校验失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	report, err := logic.RebuildRedis(req.DryRun)
+	if err != nil {
+		zap.L().Error("重建 redis 数据失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, report)
+}
+
+// GetRedisDriftHandler 查看最近一次 redis 一致性校验报告
+func GetRedisDriftHandler(c *gin.Context) {
+	report, err := logic.GetRedisDriftReport()
+	if err != nil {
+		if errors.Is(err, constants.ErrorNoResult) {
+			ResponseError(c, http.StatusOK, constants.CodeNoResult)
+			return
+		}
+		zap.L().Error("获取 redis 一致性校验报告失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, report)
+}
+
+// ReconcileRedisHandler 立即执行一次 redis 一致性校验
+func ReconcileRedisHandler(c *gin.Context) {
+	req := new(request.ReconcileRedisRequest)
+	if err := c.ShouldBindQuery(req); err != nil {
+		zap.L().Error("参数校验失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	report, err := logic.ReconcileRedis(req.Mode, req.DryRun)
+	if err != nil {
+		if errors.Is(err, constants.ErrorTaskRunning) {
+			ResponseError(c, http.StatusConflict, constants.CodeTaskRunning)
+			return
+		}
+		zap.L().Error("redis 一致性校验失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, report)
+}
+
+// ListDeadLettersHandler 查看 kafka 死信主题中最近的消息
+func ListDeadLettersHandler(c *gin.Context) {
+	req := new(request.ListDeadLettersRequest)
+	if err := c.ShouldBindQuery(req); err != nil {
+		zap.L().Error("参数校验失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	letters, err := logic.ListDeadLetters(c.Request.Context(), req.Size)
+	if err != nil {
+		if errors.Is(err, constants.ErrKafkaNotEnabled) {
+			ResponseError(c, http.StatusServiceUnavailable, constants.CodeErrKafkaNotEnabled)
+			return
+		}
+		zap.L().Error("查看死信消息失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, letters)
+}
+
+// ReplayDeadLetterHandler 把死信消息重新投递到原主题
+func ReplayDeadLetterHandler(c *gin.Context) {
+	partition, err := strconv.Atoi(c.Param("partition"))
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+	offset, err := strconv.ParseInt(c.Param("offset"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	letter, err := logic.ReplayDeadLetter(c.Request.Context(), partition, offset)
+	if err != nil {
+		if errors.Is(err, constants.ErrKafkaNotEnabled) {
+			ResponseError(c, http.StatusServiceUnavailable, constants.CodeErrKafkaNotEnabled)
+			return
+		} else if errors.Is(err, constants.ErrorNoDeadLetter) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoDeadLetter)
+			return
+		}
+		zap.L().Error("重新投递死信消息失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, letter)
+}
+
+// GetOutboxStatsHandler 查看 kafka 发件箱积压和转发情况
+func GetOutboxStatsHandler(c *gin.Context) {
+	stats, err := logic.GetOutboxStats()
+	if err != nil {
+		if errors.Is(err, constants.ErrKafkaNotEnabled) {
+			ResponseError(c, http.StatusServiceUnavailable, constants.CodeErrKafkaNotEnabled)
+			return
+		}
+		zap.L().Error("查询发件箱转发指标失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, stats)
+}
+
+// parseModeratorParams 解析路径中的社区id和用户id
+func parseModeratorParams(c *gin.Context) (communityID, userID int64, err error) {
+	if communityID, err = strconv.ParseInt(c.Param("id"), 10, 64); err != nil {
+		return
+	}
+	userID, err = strconv.ParseInt(c.Param("user_id"), 10, 64)
+	return
+}
+
+// AddCommunityModeratorHandler 任命社区版主
+func AddCommunityModeratorHandler(c *gin.Context) {
+	communityID, userID, err := parseModeratorParams(c)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	if err := logic.AddCommunityModerator(communityID, userID); err != nil {
+		zap.L().Error("任命社区版主失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoResult) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoResult)
+			return
+		} else if errors.Is(err, constants.ErrorUserNotExist) {
+			ResponseError(c, http.StatusNotFound, constants.CodeUserNotExist)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RemoveCommunityModeratorHandler 撤销社区版主
+func RemoveCommunityModeratorHandler(c *gin.Context) {
+	communityID, userID, err := parseModeratorParams(c)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	if err := logic.RemoveCommunityModerator(communityID, userID); err != nil {
+		zap.L().Error("撤销社区版主失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
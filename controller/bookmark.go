@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/logic"
+	"vision/middleware"
+	"vision/models/request"
+)
+
+// 收藏模块
+
+// 收藏帖子
+func BookmarkPostHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 请求体可选，不传时收藏到未分类
+	p := new(request.BookmarkPostRequest)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(p); err != nil {
+			zap.L().Error("请求参数错误", zap.Error(err))
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	if err := logic.BookmarkPost(postID, userID, p); err != nil {
+		zap.L().Error("收藏帖子失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoPost) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+			return
+		} else if errors.Is(err, constants.ErrorNoBookmarkFolder) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeNoBookmarkFolder)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// 取消收藏帖子
+func UnbookmarkPostHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	if err := logic.UnbookmarkPost(postID, userID); err != nil {
+		zap.L().Error("取消收藏帖子失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// 获取用户收藏的帖子列表
+func GetUserBookmarkedPostListHandler(c *gin.Context) {
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	listRequest := &request.BookmarkListRequest{
+		ListRequest: request.ListRequest{
+			Page:  1,
+			Size:  10,
+			Order: constants.OrderTime,
+		},
+	}
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		zap.L().Error("参数校验失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	data, err := logic.GetUserBookmarkedPostList(userID, listRequest)
+	if err != nil {
+		zap.L().Error("获取用户收藏帖子列表失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoBookmarkFolder) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeNoBookmarkFolder)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 获取用户的收藏夹列表
+func GetBookmarkFolderListHandler(c *gin.Context) {
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.GetBookmarkFolderList(userID)
+	if err != nil {
+		zap.L().Error("获取收藏夹列表失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 创建收藏夹
+func CreateBookmarkFolderHandler(c *gin.Context) {
+	p := new(request.CreateBookmarkFolderRequest)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.CreateBookmarkFolder(userID, p)
+	if err != nil {
+		zap.L().Error("创建收藏夹失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorBookmarkFolderExist) {
+			ResponseError(c, http.StatusConflict, constants.CodeBookmarkFolderExist)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 删除收藏夹
+func DeleteBookmarkFolderHandler(c *gin.Context) {
+	folderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	if err := logic.DeleteBookmarkFolder(folderID, userID); err != nil {
+		zap.L().Error("删除收藏夹失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoBookmarkFolder) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoBookmarkFolder)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -81,6 +81,9 @@ func DeleteCommentHandler(c *gin.Context) {
 		} else if errors.Is(err, constants.ErrorNoPermission) {
 			ResponseError(c, http.StatusBadRequest, constants.CodeNoPermission)
 			return
+		} else if errors.Is(err, constants.ErrorNotAffectData) { // 评论已被并发删除
+			ResponseError(c, http.StatusConflict, constants.CodeNotAffectData)
+			return
 		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
@@ -89,6 +92,49 @@ func DeleteCommentHandler(c *gin.Context) {
 	ResponseSuccess(c, nil)
 }
 
+// 编辑评论
+func UpdateCommentHandler(c *gin.Context) {
+	commentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("参数不正确", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	p := new(request.UpdateCommentRequest)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("参数不正确", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 获取userID
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.UpdateComment(commentID, userID, p)
+	if err != nil {
+		zap.L().Error("编辑评论失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoComment) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeNoComment)
+			return
+		} else if errors.Is(err, constants.ErrorNoPermission) {
+			ResponseError(c, http.StatusForbidden, constants.CodeNoPermission)
+			return
+		} else if errors.Is(err, constants.ErrorNotAffectData) { // 并发编辑，版本号已变化
+			ResponseError(c, http.StatusConflict, constants.CodeNotAffectData)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
 // 查询顶级评论
 func GetTopCommentListHandler(c *gin.Context) {
 	// 获取参数
@@ -120,6 +166,10 @@ func GetTopCommentListHandler(c *gin.Context) {
 	commentListResponse, err := logic.GetTopCommentList(postID, listRequest, userID)
 	if err != nil {
 		zap.L().Error("查询顶级评论失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
@@ -155,12 +205,62 @@ func GetSonCommentListHandler(c *gin.Context) {
 	commentListResponse, err := logic.GetSonCommentList(commentID, listRequest, userID)
 	if err != nil {
 		zap.L().Error("查询子评论失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
 	ResponseSuccess(c, commentListResponse)
 }
 
+// 查询帖子的评论树
+func GetCommentTreeHandler(c *gin.Context) {
+	postID, err1 := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	treeRequest := &request.CommentTreeRequest{
+		ListRequest: request.ListRequest{
+			Page:  1,
+			Size:  10,
+			Order: constants.OrderTime,
+		},
+	}
+	err2 := c.ShouldBindQuery(treeRequest)
+
+	if err1 != nil || err2 != nil {
+		zap.L().Error("参数不正确", zap.Error(err1), zap.Error(err2))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 获取userID
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	treeResponse, err := logic.GetCommentTree(postID, treeRequest, userID)
+	if err != nil {
+		zap.L().Error("查询评论树失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		} else if errors.Is(err, constants.ErrorNoPost) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeNoPost)
+			return
+		} else if errors.Is(err, constants.ErrorNoComment) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeNoComment)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, treeResponse)
+}
+
 // 查询帖子的所有评论
 func GetCommentListHandler(c *gin.Context) {
 	// 绑定参数
@@ -187,6 +287,10 @@ func GetCommentListHandler(c *gin.Context) {
 	commentListResponse, err := logic.GetCommentList(postID, listRequest, userID)
 	if err != nil {
 		zap.L().Error("查询评论失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/logic"
+	"vision/middleware"
+	"vision/models/request"
+)
+
+// 草稿模块
+
+// 获取用户的草稿和定时发布的帖子列表
+func GetUserDraftListHandler(c *gin.Context) {
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	listRequest := &request.ListRequest{
+		Page:  1,
+		Size:  10,
+		Order: constants.OrderTime,
+	}
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		zap.L().Error("参数校验失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	data, err := logic.GetUserDraftList(userID, listRequest)
+	if err != nil {
+		zap.L().Error("获取用户草稿列表失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 编辑草稿
+func UpdateDraftHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+	p := new(request.UpdateDraftRequest)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.UpdateDraft(postID, userID, p)
+	if err != nil {
+		zap.L().Error("编辑草稿失败", zap.Error(err))
+		responseDraftError(c, err)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 发布草稿
+func PublishDraftHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 请求体可选，不传时立即发布
+	p := new(request.PublishDraftRequest)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(p); err != nil {
+			zap.L().Error("请求参数错误", zap.Error(err))
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.PublishDraft(postID, userID, p)
+	if err != nil {
+		zap.L().Error("发布草稿失败", zap.Error(err))
+		responseDraftError(c, err)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 草稿接口共用的错误响应
+func responseDraftError(c *gin.Context, err error) {
+	if errors.Is(err, constants.ErrorNoPermission) {
+		ResponseError(c, http.StatusForbidden, constants.CodeNoPermission)
+		return
+	} else if errors.Is(err, constants.ErrorNoPost) {
+		ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+		return
+	} else if errors.Is(err, constants.ErrorPostPublished) {
+		ResponseError(c, http.StatusConflict, constants.CodePostPublished)
+		return
+	} else if errors.Is(err, constants.ErrorInvalidParam) { // 定时发布时间格式错误
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+	ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+}
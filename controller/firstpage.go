@@ -79,7 +79,7 @@ func GetPoetryHandler(c *gin.Context) {
 }
 
 // UploadMaterialHandler 通用素材上传接口 (图片/视频)
-// 参考了 UploadPostImageHandler 的逻辑
+// 参考了 UploadPostAttachmentHandler 的逻辑
 func UploadMaterialHandler(c *gin.Context) {
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
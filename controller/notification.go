@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/logic"
+	"vision/middleware"
+	"vision/models/request"
+)
+
+// 查询当前用户的通知列表（分页）
+func GetUserNotificationListHandler(c *gin.Context) {
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	listRequest := &request.ListRequest{
+		Page: 1,
+		Size: 20,
+	}
+	if err := c.ShouldBindQuery(listRequest); err != nil || listRequest.Page < 1 || listRequest.Size < 1 {
+		zap.L().Error("参数校验失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	data, err := logic.GetUserNotificationList(userID, listRequest)
+	if err != nil {
+		zap.L().Error("查询通知列表失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 把当前用户的所有通知标记为已读
+func ReadAllNotificationsHandler(c *gin.Context) {
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("获取userID失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	if err := logic.ReadAllNotifications(userID); err != nil {
+		zap.L().Error("标记通知已读失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/logic"
+	"vision/middleware"
+	"vision/models/request"
+)
+
+// 置顶模块
+
+// 置顶帖子
+func PinPostHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 请求体可选，不传时永久置顶到帖子所属社区
+	p := new(request.PinPostRequest)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(p); err != nil {
+			zap.L().Error("请求参数错误", zap.Error(err))
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	if err := logic.PinPost(postID, userID, p); err != nil {
+		zap.L().Error("置顶帖子失败", zap.Error(err))
+		responsePinError(c, err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// 取消置顶帖子（?scope=global 取消全站置顶）
+func UnpinPostHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+	p := new(request.PinPostRequest)
+	if err := c.ShouldBindQuery(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	if err := logic.UnpinPost(postID, userID, p.Scope); err != nil {
+		zap.L().Error("取消置顶帖子失败", zap.Error(err))
+		responsePinError(c, err)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// 置顶接口共用的错误响应
+func responsePinError(c *gin.Context, err error) {
+	if errors.Is(err, constants.ErrorNoPermission) {
+		ResponseError(c, http.StatusForbidden, constants.CodeNoPermission)
+		return
+	} else if errors.Is(err, constants.ErrorNoPost) {
+		ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+		return
+	} else if errors.Is(err, constants.ErrorPinLimit) {
+		ResponseError(c, http.StatusConflict, constants.CodePinLimit)
+		return
+	} else if errors.Is(err, constants.ErrorInvalidParam) { // 到期时间格式错误或已过去
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+	ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+}
@@ -2,14 +2,12 @@ package controller
 
 import (
 	"errors"
-	"fmt"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strconv"
-	"time"
-	"vision/pkg/alioss"
-	"vision/settings"
+	"strings"
+	"vision/models/entity"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -41,6 +39,14 @@ func CreatePostHandlerAsync(c *gin.Context) {
 	data, err := logic.CreatePostAsync(p, userID)
 	if err != nil {
 		zap.L().Error("异步创建帖子失败", zap.Error(err))
+		// 草稿、定时发布和带附件的帖子会同步创建，参数错误与同步接口一致
+		if errors.Is(err, constants.ErrorInvalidAttachment) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidAttachment)
+			return
+		} else if errors.Is(err, constants.ErrorInvalidParam) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeKafkaSendFailed)
 		return
 	}
@@ -70,6 +76,13 @@ func CreatePostHandler(c *gin.Context) {
 	data, err := logic.CreatePost(p, userID)
 	if err != nil {
 		zap.L().Error("创建帖子失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidAttachment) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidAttachment)
+			return
+		} else if errors.Is(err, constants.ErrorInvalidParam) { // 定时发布时间格式错误
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
@@ -111,6 +124,127 @@ func DeletePostHandler(c *gin.Context) {
 	ResponseSuccess(c, nil)
 }
 
+// 编辑帖子
+func UpdatePostHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	p := new(request.UpdatePostRequest)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	//在请求上下文中获取userID
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.UpdatePost(postID, userID, p)
+	if err != nil {
+		zap.L().Error("编辑帖子失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoPermission) {
+			ResponseError(c, http.StatusForbidden, constants.CodeNoPermission)
+			return
+		} else if errors.Is(err, constants.ErrorNoPost) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+			return
+		} else if errors.Is(err, constants.ErrorPostNotPublished) { // 草稿需通过草稿接口修改
+			ResponseError(c, http.StatusBadRequest, constants.CodePostNotPublished)
+			return
+		} else if errors.Is(err, constants.ErrorNotAffectData) { // 并发编辑，版本号已变化
+			ResponseError(c, http.StatusConflict, constants.CodeNotAffectData)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 查询帖子详情
+func GetPostDetailHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 获取userID，未登录时userID为0，以游客身份查询
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	data, err := logic.GetPostDetail(postID, userID)
+	if err != nil {
+		zap.L().Error("查询帖子详情失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoPost) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 查询异步发帖状态（pending / created / failed）
+func GetPostCreationStatusHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	//在请求上下文中获取userID
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.GetPostCreationStatus(postID, userID)
+	if err != nil {
+		zap.L().Error("查询发帖状态失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoPost) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 查询帖子历史版本
+func GetPostRevisionListHandler(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	data, err := logic.GetPostRevisionList(postID)
+	if err != nil {
+		zap.L().Error("查询帖子历史版本失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorNoPost) {
+			ResponseError(c, http.StatusNotFound, constants.CodeNoPost)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
 // 查询帖子列表
 func GetPostListHandler(c *gin.Context) {
 	//初始化结构体时指定初始默认参数
@@ -133,6 +267,10 @@ func GetPostListHandler(c *gin.Context) {
 		data, err := logic.GetPostList(p, 0)
 		if err != nil {
 			zap.L().Error("指定顺序查询帖子列表失败", zap.Error(err))
+			if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+				ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+				return
+			}
 			ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 			return
 		}
@@ -144,6 +282,10 @@ func GetPostListHandler(c *gin.Context) {
 	data, err := logic.GetPostList(p, userID)
 	if err != nil {
 		zap.L().Error("指定顺序查询帖子列表失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
@@ -176,6 +318,10 @@ func GetCommunityPostListHandler(c *gin.Context) {
 		data, err := logic.GetCommunityPostList(p, communityID, 0)
 		if err != nil {
 			zap.L().Error("根据社区查询帖子列表失败", zap.Error(err))
+			if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+				ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+				return
+			}
 			ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 			return
 		}
@@ -187,6 +333,10 @@ func GetCommunityPostListHandler(c *gin.Context) {
 	data, err := logic.GetCommunityPostList(p, communityID, userID)
 	if err != nil {
 		zap.L().Error("根据社区查询帖子列表失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
@@ -246,13 +396,17 @@ func GetUserLikedPostListHandler(c *gin.Context) {
 	data, err := logic.GetUserLikedPostList(userID, listRequest)
 	if err != nil {
 		zap.L().Error("获取用户点赞帖子列表失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) { // 分页游标无效
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
 		return
 	}
 	ResponseSuccess(c, data)
 }
 
-// 上传帖子图片
+// 上传帖子图片，返回图片地址（用于帖子的 image 字段），未被帖子使用的图片会在一段时间后自动清理
 func UploadPostImageHandler(c *gin.Context) {
 	// 获取上传的文件
 	file, header, err := c.Request.FormFile("file")
@@ -267,24 +421,26 @@ func UploadPostImageHandler(c *gin.Context) {
 
 	// 限制文件大小（5MB）
 	if header.Size > 5*1024*1024 {
-		zap.L().Error("文件大小超出5MB", zap.Error(err))
+		zap.L().Error("文件大小超出5MB", zap.Int64("size", header.Size))
 		ResponseError(c, http.StatusBadRequest, "文件大小超出5MB")
 		return
 	}
 
 	// 获取文件扩展名ext
-	ext := filepath.Ext(header.Filename)
+	ext := strings.ToLower(filepath.Ext(header.Filename))
 	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		zap.L().Error("文件格式不支持", zap.Error(err))
+		zap.L().Error("文件格式不支持", zap.String("ext", ext))
 		ResponseError(c, http.StatusBadRequest, "文件格式不支持")
 		return
 	}
 
-	// 生成唯一文件名
-	newFileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
 
-	// 上传到 OSS
-	fileURL, err := alioss.UploadFile(file, newFileName, settings.Conf.AliossConfig.PostImagePtah)
+	fileURL, err := logic.UploadPostImage(userID, file, ext, header.Size)
 	if err != nil {
 		zap.L().Error("上传文件失败", zap.Error(err))
 		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
@@ -292,3 +448,64 @@ func UploadPostImageHandler(c *gin.Context) {
 	}
 	ResponseSuccess(c, fileURL)
 }
+
+// 上传帖子附件（图片或短视频），未被帖子使用的附件会在一段时间后自动清理
+func UploadPostAttachmentHandler(c *gin.Context) {
+	// 获取上传的文件
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		zap.L().Error("获取上传文件失败", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+	defer func(file multipart.File) {
+		_ = file.Close()
+	}(file)
+
+	p := new(request.UploadAttachmentRequest)
+	if err := c.ShouldBind(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 获取文件扩展名ext，确定媒体类型
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	var mediaType string
+	switch ext {
+	case ".jpg", ".jpeg", ".png":
+		mediaType = entity.MediaTypeImage
+		// 限制图片大小（5MB）
+		if header.Size > 5*1024*1024 {
+			zap.L().Error("文件大小超出5MB", zap.Int64("size", header.Size))
+			ResponseError(c, http.StatusBadRequest, "文件大小超出5MB")
+			return
+		}
+	case ".mp4", ".mov", ".webm":
+		mediaType = entity.MediaTypeVideo
+		// 限制视频大小（50MB）
+		if header.Size > 50*1024*1024 {
+			zap.L().Error("文件大小超出50MB", zap.Int64("size", header.Size))
+			ResponseError(c, http.StatusBadRequest, "文件大小超出50MB")
+			return
+		}
+	default:
+		zap.L().Error("文件格式不支持", zap.String("ext", ext))
+		ResponseError(c, http.StatusBadRequest, "文件格式不支持")
+		return
+	}
+
+	userID, err := middleware.GetCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+
+	data, err := logic.UploadPostAttachment(userID, file, ext, mediaType, header.Size, p)
+	if err != nil {
+		zap.L().Error("上传文件失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
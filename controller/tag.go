@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/logic"
+	"vision/middleware"
+	"vision/models/request"
+)
+
+// 话题标签模块
+
+// 查询标签下的帖子列表（登录和游客共用）
+func GetTagPostListHandler(c *gin.Context) {
+	//初始化结构体时指定初始默认参数
+	p := &request.ListRequest{
+		Page:  1,
+		Size:  10,
+		Order: constants.OrderTime,
+	}
+	if err := c.ShouldBindQuery(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	// 获取userID，未登录时userID为0，以游客身份查询
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	data, err := logic.GetTagPostList(c.Param("name"), p, userID)
+	if err != nil {
+		zap.L().Error("根据标签查询帖子列表失败", zap.Error(err))
+		if errors.Is(err, constants.ErrorInvalidParam) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+			return
+		}
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// 查询热门标签
+func GetTrendingTagsHandler(c *gin.Context) {
+	p := new(request.TrendingTagsRequest)
+	if err := c.ShouldBindQuery(p); err != nil {
+		zap.L().Error("请求参数错误", zap.Error(err))
+		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
+		return
+	}
+
+	data, err := logic.GetTrendingTags(p)
+	if err != nil {
+		zap.L().Error("查询热门标签失败", zap.Error(err))
+		ResponseError(c, http.StatusInternalServerError, constants.CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
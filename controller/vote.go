@@ -45,6 +45,10 @@ func PostVoteController(c *gin.Context) {
 			ResponseError(c, http.StatusBadRequest, constants.CodeNoPost)
 			return
 		}
+		if errors.Is(err, constants.ErrorVoteTimeExpire) {
+			ResponseError(c, http.StatusBadRequest, constants.CodeVoteTimeExpire)
+			return
+		}
 		ResponseError(c, http.StatusBadRequest, constants.CodeInvalidParam)
 		return
 	}
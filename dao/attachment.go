@@ -0,0 +1,131 @@
+package dao
+
+import (
+	"time"
+	"vision/dao/postgres"
+
+	"gorm.io/gorm"
+
+	"vision/constants"
+	"vision/models/entity"
+)
+
+// 保存上传的附件（待使用状态）
+func CreateAttachment(attachment *entity.PostAttachment) error {
+	result := postgres.DB.Create(attachment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return constants.ErrorNotAffectData
+	}
+	return nil
+}
+
+// 在同一个事务中创建帖子并按顺序绑定附件，同时绑定帖子 image 字段引用的图片
+// 附件必须由作者本人上传、尚未被使用且未过期，否则返回 ErrorInvalidAttachment
+func CreatePostWithAttachments(p *entity.Post, attachmentIDs []int64) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(p)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return constants.ErrorNotAffectData
+		}
+
+		if err := bindImageAttachment(tx, p.ID, p.AuthorID, p.Image); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i, id := range attachmentIDs {
+			result := tx.Model(&entity.PostAttachment{}).
+				Where("id = ? AND uploader_id = ? AND post_id IS NULL AND expires_at > ?", id, p.AuthorID, now).
+				Updates(map[string]interface{}{
+					"post_id":    p.ID,
+					"sort":       i,
+					"expires_at": nil,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return constants.ErrorInvalidAttachment
+			}
+		}
+		return nil
+	})
+}
+
+// 将帖子 image 字段引用的、由作者本人上传且尚未使用的图片绑定到帖子
+// 找不到对应的附件记录时（例如旧数据或外部图片地址）不做处理
+func BindImageAttachment(postID, uploaderID int64, image string) error {
+	return bindImageAttachment(postgres.DB, postID, uploaderID, image)
+}
+
+func bindImageAttachment(tx *gorm.DB, postID, uploaderID int64, image string) error {
+	if image == "" {
+		return nil
+	}
+	return tx.Model(&entity.PostAttachment{}).
+		Where("url = ? AND uploader_id = ? AND post_id IS NULL AND expires_at > ?", image, uploaderID, time.Now()).
+		Updates(map[string]interface{}{
+			"post_id":    postID,
+			"sort":       entity.ImageAttachmentSort,
+			"expires_at": nil,
+		}).Error
+}
+
+// 批量查询帖子的附件，返回 postID -> 按顺序排列的附件列表（不包含帖子 image 字段使用的图片）
+func GetPostAttachments(postIDs []int64) (map[int64][]*entity.PostAttachment, error) {
+	attachments := make(map[int64][]*entity.PostAttachment, len(postIDs))
+	if len(postIDs) == 0 {
+		return attachments, nil
+	}
+
+	var rows []*entity.PostAttachment
+	result := postgres.DB.Where("post_id IN ? AND sort >= 0", postIDs).Order("sort ASC").Find(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, row := range rows {
+		attachments[*row.PostID] = append(attachments[*row.PostID], row)
+	}
+	return attachments, nil
+}
+
+// 删除帖子时解除附件绑定并标记为已过期，由清理任务删除 OSS 中的文件和附件记录
+func DeletePostAttachments(postID int64) error {
+	return postgres.DB.Model(&entity.PostAttachment{}).
+		Where("post_id = ?", postID).
+		Updates(map[string]interface{}{
+			"post_id":    nil,
+			"expires_at": time.Now(),
+		}).Error
+}
+
+// 查询在 before 之前过期且仍未使用的附件
+func GetExpiredAttachments(before time.Time, limit int) ([]*entity.PostAttachment, error) {
+	var attachments []*entity.PostAttachment
+	result := postgres.DB.
+		Where("post_id IS NULL AND expires_at < ?", before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&attachments)
+	return attachments, result.Error
+}
+
+// 推迟未使用附件的过期时间，清理失败时使用，避免同一附件反复阻塞清理
+func DelayExpiredAttachment(id int64, expiresAt time.Time) error {
+	return postgres.DB.Model(&entity.PostAttachment{}).
+		Where("id = ? AND post_id IS NULL", id).
+		Update("expires_at", expiresAt).Error
+}
+
+// 物理删除未使用的附件记录（只删除仍未绑定帖子的记录，避免与发帖并发冲突）
+func DeleteExpiredAttachment(id int64) error {
+	return postgres.DB.Unscoped().
+		Where("id = ? AND post_id IS NULL", id).
+		Delete(&entity.PostAttachment{}).Error
+}
@@ -0,0 +1,179 @@
+package dao
+
+import (
+	"errors"
+	"strconv"
+	"vision/dao/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vision/constants"
+	"vision/models/entity"
+	"vision/models/request"
+)
+
+// 收藏帖子，已收藏时移动到新的收藏夹
+func BookmarkPost(userID, postID int64, folderID *int64) error {
+	bookmark := entity.PostBookmark{
+		UserID:   userID,
+		PostID:   postID,
+		FolderID: folderID,
+	}
+
+	// 当 user_id + post_id 冲突时，更新 folder_id 和 updated_at
+	return postgres.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"folder_id", "updated_at"}),
+	}).Create(&bookmark).Error
+}
+
+// 取消收藏帖子（物理删除，避免软删除的记录占用唯一索引）
+func UnbookmarkPost(userID, postID int64) error {
+	return postgres.DB.Unscoped().
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&entity.PostBookmark{}).Error
+}
+
+// 删除帖子的所有收藏记录
+func DeletePostBookmarks(postID int64) error {
+	return postgres.DB.Unscoped().
+		Where("post_id = ?", postID).
+		Delete(&entity.PostBookmark{}).Error
+}
+
+// 查询用户收藏的帖子ID列表（按收藏时间倒序）
+// folderID 为 nil 时查询全部收藏，为 0 时查询未分类的收藏
+func GetUserBookmarkedPostIDs(p *request.ListRequest, userID int64, folderID *int64) (ids []string, total int64, err error) {
+	db := postgres.DB.Model(&entity.PostBookmark{}).Where("user_id = ?", userID)
+	if folderID != nil {
+		if *folderID == 0 {
+			db = db.Where("folder_id IS NULL")
+		} else {
+			db = db.Where("folder_id = ?", *folderID)
+		}
+	}
+
+	// 1. 统计总数
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	if total == 0 {
+		return
+	}
+
+	// 2. 排序并分页取 PostID（移动收藏夹会更新 updated_at，所以按 created_at 排序）
+	offset := (p.Page - 1) * p.Size
+	var intIDs []int64
+	err = db.Order("created_at DESC").Limit(int(p.Size)).Offset(int(offset)).Pluck("post_id", &intIDs).Error
+	if err != nil {
+		return
+	}
+
+	// 3. 格式转换
+	ids = make([]string, len(intIDs))
+	for i, v := range intIDs {
+		ids[i] = strconv.FormatInt(v, 10)
+	}
+	return
+}
+
+// 查询一批帖子中用户已收藏的帖子
+func GetBookmarkedPostIDs(userID int64, postIDs []int64) (map[int64]bool, error) {
+	bookmarked := make(map[int64]bool, len(postIDs))
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	var ids []int64
+	err := postgres.DB.Model(&entity.PostBookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
+
+// 创建收藏夹，同一用户已有同名收藏夹时返回 ErrorBookmarkFolderExist
+// 依赖 (user_id, name) 唯一索引判断重名，并发创建同名收藏夹时只有一个成功
+func CreateBookmarkFolder(folder *entity.BookmarkFolder) error {
+	result := postgres.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(folder)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return constants.ErrorBookmarkFolderExist
+	}
+	return nil
+}
+
+// 查询用户的收藏夹，不存在或不属于该用户时返回 ErrorNoBookmarkFolder
+func GetBookmarkFolder(folderID, userID int64) (*entity.BookmarkFolder, error) {
+	var folder entity.BookmarkFolder
+	result := postgres.DB.Where("id = ? AND user_id = ?", folderID, userID).First(&folder)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, constants.ErrorNoBookmarkFolder
+	}
+	return &folder, result.Error
+}
+
+// 查询用户的所有收藏夹（按创建时间正序）
+func GetBookmarkFolders(userID int64) ([]*entity.BookmarkFolder, error) {
+	var folders []*entity.BookmarkFolder
+	result := postgres.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&folders)
+	return folders, result.Error
+}
+
+// 统计用户每个收藏夹中的收藏数，返回 folderID -> 数量，未分类的收藏记在 0 下
+func CountUserBookmarksByFolder(userID int64) (map[int64]int64, error) {
+	var rows []struct {
+		FolderID *int64
+		Count    int64
+	}
+	err := postgres.DB.Model(&entity.PostBookmark{}).
+		Select("folder_id, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("folder_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		if row.FolderID == nil {
+			counts[0] = row.Count
+			continue
+		}
+		counts[*row.FolderID] = row.Count
+	}
+	return counts, nil
+}
+
+// 删除收藏夹，其中的收藏移回未分类
+func DeleteBookmarkFolder(folderID, userID int64) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.PostBookmark{}).
+			Where("user_id = ? AND folder_id = ?", userID, folderID).
+			Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+
+		// 物理删除，释放收藏夹名称
+		result := tx.Unscoped().Where("id = ? AND user_id = ?", folderID, userID).Delete(&entity.BookmarkFolder{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return constants.ErrorNoBookmarkFolder
+		}
+		return nil
+	})
+}
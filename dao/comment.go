@@ -1,10 +1,14 @@
 package dao
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 	"vision/dao/postgres"
 
+	"gorm.io/gorm"
+
 	"vision/constants"
 	"vision/models/entity"
 )
@@ -24,19 +28,128 @@ func CreateComment(comment *entity.Comment) error {
 	return nil
 }
 
-// 删除评论
-func DeleteComment(commentID int64) error {
-	// 判断是否是一级评论
-
-	result := postgres.DB.Delete(&entity.Comment{}, commentID)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return constants.ErrorNotAffectData
+// 根据评论id查询评论
+func GetCommentByID(commentID int64) (*entity.Comment, error) {
+	var comment entity.Comment
+	if err := postgres.DB.Where("id = ?", commentID).First(&comment).Error; err != nil {
+		return nil, err
 	}
+	return &comment, nil
+}
 
-	return nil
+// 编辑评论：更新内容后保存旧版本，管理员或版主修改他人评论时同时写入管理操作记录
+func UpdateComment(comment *entity.Comment, content string, editorID int64, log *entity.ModerationLog) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		// 先更新评论内容，revision_count 作为乐观锁，防止并发编辑丢失版本
+		// 并发编辑时只有一个请求能更新成功，失败的请求不会再写入版本记录触发唯一索引冲突
+		now := time.Now()
+		result := tx.Model(&entity.Comment{}).
+			Where("id = ? AND revision_count = ?", comment.ID, comment.RevisionCount).
+			Updates(map[string]interface{}{
+				"content":        content,
+				"edited_at":      now,
+				"revision_count": comment.RevisionCount + 1,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return constants.ErrorNotAffectData
+		}
+
+		// 更新成功后保存旧版本
+		revision := &entity.CommentRevision{
+			CommentID: comment.ID,
+			Version:   comment.RevisionCount + 1,
+			Content:   comment.Content,
+			EditorID:  editorID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		if log != nil {
+			if err := tx.Create(log).Error; err != nil {
+				return err
+			}
+		}
+
+		comment.Content = content
+		comment.EditedAt = &now
+		comment.RevisionCount++
+		return nil
+	})
+}
+
+// 删除评论：有回复的评论保留为墓碑（清空内容，子评论保留），没有回复的评论直接删除
+// 直接删除后，如果父评论是墓碑且已没有任何回复，沿着父评论链一并删除
+// 返回评论是否成为墓碑，以及随之删除的墓碑祖先评论
+func DeleteComment(comment *entity.Comment) (tombstoned bool, removed []*entity.Comment, err error) {
+	err = postgres.DB.Transaction(func(tx *gorm.DB) error {
+		var replies int64
+		if err := tx.Model(&entity.Comment{}).Where("parent_id = ?", comment.ID).Count(&replies).Error; err != nil {
+			return err
+		}
+
+		// 有回复：保留为墓碑
+		if replies > 0 {
+			now := time.Now()
+			result := tx.Model(&entity.Comment{}).
+				Where("id = ? AND tombstoned_at IS NULL", comment.ID).
+				Updates(map[string]interface{}{
+					"content":       "",
+					"tombstoned_at": now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return constants.ErrorNotAffectData
+			}
+			comment.Content = ""
+			comment.TombstonedAt = &now
+			tombstoned = true
+			return nil
+		}
+
+		result := tx.Delete(&entity.Comment{}, comment.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return constants.ErrorNotAffectData
+		}
+
+		// 清理已没有回复的墓碑祖先评论
+		for parentID := comment.ParentID; parentID != nil; {
+			var parent entity.Comment
+			if err := tx.Where("id = ?", *parentID).Take(&parent).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			if parent.TombstonedAt == nil {
+				return nil
+			}
+			if err := tx.Model(&entity.Comment{}).Where("parent_id = ?", parent.ID).Count(&replies).Error; err != nil {
+				return err
+			}
+			if replies > 0 {
+				return nil
+			}
+			if err := tx.Delete(&entity.Comment{}, parent.ID).Error; err != nil {
+				return err
+			}
+			removed = append(removed, &parent)
+			parentID = parent.ParentID
+		}
+		return nil
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	return tombstoned, removed, nil
 }
 
 /*// 根据评论ID获取父评论ID和帖子ID
@@ -108,6 +221,36 @@ func GetCommentListByIDs(ids []string) ([]*entity.Comment, error) {
 	return comments, result.Error
 }
 
+// 查询一批帖子下的所有评论（只取重建计数需要的字段）
+func GetCommentsByPostIDs(postIDs []int64) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	if len(postIDs) == 0 {
+		return comments, nil
+	}
+	result := postgres.DB.
+		Select("id", "post_id", "parent_id", "root_id", "created_at", "tombstoned_at").
+		Where("post_id IN ?", postIDs).
+		Find(&comments)
+	return comments, result.Error
+}
+
+// 查询根评论下子评论的总数
+func CountSonComments(rootID int64) (int64, error) {
+	var total int64
+	err := postgres.DB.Model(&entity.Comment{}).Where("root_id = ?", rootID).Count(&total).Error
+	return total, err
+}
+
+// 查询根评论下全部子评论的id和发布时间
+func GetSonCommentTimes(rootID int64) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	result := postgres.DB.
+		Select("id", "created_at").
+		Where("root_id = ?", rootID).
+		Find(&comments)
+	return comments, result.Error
+}
+
 // 根据分页查询子评论
 func GetSonCommentList(rootID, page, size int64) ([]*entity.Comment, int64, error) {
 	var comments []*entity.Comment
@@ -134,3 +277,86 @@ func GetSonCommentList(rootID, page, size int64) ([]*entity.Comment, int64, erro
 	}
 	return comments, total, nil
 }
+
+// 批量查询评论的作者id（包含已删除的评论），返回 commentID -> authorID
+// 已删除的评论（包括墓碑）隐藏作者，作者id为0
+func GetCommentAuthorIDs(commentIDs []int64) (map[int64]int64, error) {
+	authorIDs := make(map[int64]int64, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return authorIDs, nil
+	}
+
+	var comments []*entity.Comment
+	err := postgres.DB.Unscoped().
+		Select("id", "author_id", "deleted_at", "tombstoned_at").
+		Where("id IN ?", commentIDs).
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		if comment.DeletedAt.Valid || comment.TombstonedAt != nil {
+			authorIDs[comment.ID] = 0
+			continue
+		}
+		authorIDs[comment.ID] = comment.AuthorID
+	}
+	return authorIDs, nil
+}
+
+// 批量查询一批父评论的回复预览：每个父评论只取按发布时间正序的前 limit 条直接回复
+func GetCommentReplyPreviews(parentIDs []int64, limit int) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	if len(parentIDs) == 0 {
+		return comments, nil
+	}
+
+	// 用窗口函数给每个父评论下的回复编号，一次查询取出所有父评论的前 limit 条回复
+	sub := postgres.DB.Model(&entity.Comment{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at, id) AS rn").
+		Where("parent_id IN ?", parentIDs)
+	err := postgres.DB.
+		Raw("SELECT * FROM (?) AS t WHERE rn <= ? ORDER BY created_at, id", sub, limit).
+		Scan(&comments).Error
+	return comments, err
+}
+
+// 批量统计一批评论的直接回复数，返回 commentID -> 回复数（没有回复的评论不在结果中）
+func CountCommentReplies(parentIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID int64
+		Total    int64
+	}
+	err := postgres.DB.Model(&entity.Comment{}).
+		Select("parent_id, COUNT(*) AS total").
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ParentID] = row.Total
+	}
+	return counts, nil
+}
+
+// 按游标查询评论的直接回复（按发布时间正序），after 为 nil 时从第一条开始
+// 多查一条用来判断是否还有下一页
+func GetCommentRepliesAfter(parentID int64, after *time.Time, afterID int64, size int64) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	query := postgres.DB.Where("parent_id = ?", parentID)
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", *after, afterID)
+	}
+	result := query.
+		Order("created_at ASC, id ASC").
+		Limit(int(size) + 1).
+		Find(&comments)
+	return comments, result.Error
+}
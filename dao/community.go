@@ -47,6 +47,19 @@ func GetCommunityList() ([]*response.CommunityBriefResponse, error) {
 	return communities, result.Error
 }
 
+// 批量查询社区简略信息
+func GetCommunityBriefs(ids []int64) ([]*response.CommunityBriefResponse, error) {
+	var communities []*response.CommunityBriefResponse
+	if len(ids) == 0 {
+		return communities, nil
+	}
+	result := postgres.DB.Model(&entity.Community{}).
+		Select("id", "community_name").
+		Where("id IN ?", ids).
+		Find(&communities)
+	return communities, result.Error
+}
+
 // 根据ID获取社区详情
 func GetCommunityById(id int64) (*entity.Community, error) {
 	var community entity.Community
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"time"
+	"vision/dao/postgres"
+
+	"vision/constants"
+	"vision/models/entity"
+)
+
+// 分页查询用户的草稿和定时发布的帖子（按最后修改时间倒序）
+func GetUserDrafts(userID, page, size int64) ([]*entity.Post, int64, error) {
+	var posts []*entity.Post
+	var total int64
+
+	db := postgres.DB.Model(&entity.Post{}).
+		Where("author_id = ? AND status IN ?", userID, []string{entity.PostStatusDraft, entity.PostStatusScheduled})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	result := db.Order("updated_at DESC").
+		Limit(int(size)).
+		Offset(int(offset)).
+		Find(&posts)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return posts, total, nil
+}
+
+// 修改草稿的内容和发布计划，帖子已发布时返回 ErrorPostPublished
+func UpdateDraft(postID int64, content, image, status string, publishAt *time.Time) error {
+	return updateDraft(postID, map[string]interface{}{
+		"content":    content,
+		"image":      image,
+		"status":     status,
+		"publish_at": publishAt,
+	})
+}
+
+// 修改草稿的发布计划，帖子已发布时返回 ErrorPostPublished
+func ScheduleDraft(postID int64, publishAt time.Time) error {
+	return updateDraft(postID, map[string]interface{}{
+		"status":     entity.PostStatusScheduled,
+		"publish_at": publishAt,
+	})
+}
+
+func updateDraft(postID int64, fields map[string]interface{}) error {
+	result := postgres.DB.Model(&entity.Post{}).
+		Where("id = ? AND status <> ?", postID, entity.PostStatusPublished).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return constants.ErrorPostPublished
+	}
+	return nil
+}
+
+// 将草稿标记为已发布，发布时间和 created_at 都更新为 now
+// 返回 false 表示帖子已被其他流程发布（或已删除）
+func MarkPostPublished(postID int64, now time.Time) (bool, error) {
+	result := postgres.DB.Model(&entity.Post{}).
+		Where("id = ? AND status <> ?", postID, entity.PostStatusPublished).
+		Updates(map[string]interface{}{
+			"status":     entity.PostStatusPublished,
+			"publish_at": now,
+			"created_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 查询定时发布时间已到的帖子
+func GetDuePosts(now time.Time, limit int) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := postgres.DB.
+		Where("status = ? AND publish_at <= ?", entity.PostStatusScheduled, now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Find(&posts)
+	return posts, result.Error
+}
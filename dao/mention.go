@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"vision/dao/postgres"
+
+	"gorm.io/gorm"
+
+	"vision/models/entity"
+)
+
+// 替换帖子或评论的提及记录（commentID 为0表示帖子正文），mentions 为空时只删除原有记录
+func ReplaceMentions(postID, commentID int64, mentions []*entity.Mention) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		// 提及记录按位置唯一，直接物理删除
+		if err := tx.Unscoped().
+			Where("post_id = ? AND comment_id = ?", postID, commentID).
+			Delete(&entity.Mention{}).Error; err != nil {
+			return err
+		}
+		if len(mentions) == 0 {
+			return nil
+		}
+		return tx.Create(&mentions).Error
+	})
+}
+
+// 批量查询帖子正文中的提及（按位置排序）
+func GetPostMentions(postIDs []int64) ([]*entity.Mention, error) {
+	var mentions []*entity.Mention
+	if len(postIDs) == 0 {
+		return mentions, nil
+	}
+	result := postgres.DB.
+		Where("post_id IN ? AND comment_id = 0", postIDs).
+		Order("span_start").
+		Find(&mentions)
+	return mentions, result.Error
+}
+
+// 批量查询评论中的提及（按位置排序）
+func GetCommentMentions(commentIDs []int64) ([]*entity.Mention, error) {
+	var mentions []*entity.Mention
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+	result := postgres.DB.
+		Where("comment_id IN ?", commentIDs).
+		Order("span_start").
+		Find(&mentions)
+	return mentions, result.Error
+}
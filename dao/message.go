@@ -0,0 +1,24 @@
+package dao
+
+import (
+	"vision/dao/postgres"
+
+	"gorm.io/gorm/clause"
+
+	"vision/models/entity"
+)
+
+// 消息是否已处理
+func IsMessageProcessed(messageID string) (bool, error) {
+	var count int64
+	err := postgres.DB.Model(&entity.ProcessedMessage{}).
+		Where("message_id = ?", messageID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// 记录消息已处理，重复记录时忽略
+func MarkMessageProcessed(messageID, topic string) error {
+	return postgres.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.ProcessedMessage{MessageID: messageID, Topic: topic}).Error
+}
@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"time"
+	"vision/dao/postgres"
+
+	"gorm.io/gorm/clause"
+
+	"vision/models/entity"
+)
+
+// 批量创建通知，同一事件已通知过的用户跳过
+func CreateNotifications(notifications []*entity.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return postgres.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&notifications).Error
+}
+
+// 分页查询用户的通知（按时间倒序）
+func GetUserNotifications(userID, page, size int64) ([]*entity.Notification, int64, error) {
+	var notifications []*entity.Notification
+	var total int64
+
+	if err := postgres.DB.Model(&entity.Notification{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := postgres.DB.
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(int(size)).
+		Offset(int((page - 1) * size)).
+		Find(&notifications)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return notifications, total, nil
+}
+
+// 查询用户的未读通知数
+func CountUnreadNotifications(userID int64) (int64, error) {
+	var count int64
+	err := postgres.DB.Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// 把用户的所有未读通知标记为已读，返回标记的数量
+func MarkNotificationsRead(userID int64) (int64, error) {
+	result := postgres.DB.Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
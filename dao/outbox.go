@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"time"
+	"vision/dao/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vision/models/entity"
+)
+
+// 在同一个事务中执行业务写入（fn 可以为 nil）并写入待发送消息，保证两者同时成功或失败
+func WithOutbox(fn func(tx *gorm.DB) error, msgs ...*entity.OutboxMessage) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		if fn != nil {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+		return tx.Create(&msgs).Error
+	})
+}
+
+// 按写入顺序领取一批未发送、未搁置且不在其他实例租约中的消息，领取后设置租约到期时间
+// 领取时加行锁并跳过其他实例已锁定的行，事务在领取后立即提交，发送消息时不持有数据库锁
+func ClaimOutboxMessages(limit int, lease time.Duration) ([]*entity.OutboxMessage, error) {
+	var msgs []*entity.OutboxMessage
+	err := postgres.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND parked_at IS NULL").
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("id").
+			Limit(limit).
+			Find(&msgs).Error; err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(msgs))
+		for i, msg := range msgs {
+			ids[i] = msg.ID
+		}
+		return tx.Model(&entity.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("locked_until", now.Add(lease)).Error
+	})
+	return msgs, err
+}
+
+// 标记消息已发送
+func MarkOutboxMessagesSent(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return postgres.DB.Model(&entity.OutboxMessage{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"sent_at":      time.Now(),
+			"locked_until": nil,
+		}).Error
+}
+
+// 记录消息发送失败并释放租约，失败次数达到 maxAttempts 时搁置该消息，返回是否已搁置
+// 消息在租约期内只由当前实例处理，失败次数可以直接在领取时的基础上累加
+func MarkOutboxMessageFailed(msg *entity.OutboxMessage, reason string, maxAttempts int) (bool, error) {
+	updates := map[string]interface{}{
+		"attempts":     msg.Attempts + 1,
+		"last_error":   reason,
+		"locked_until": nil,
+	}
+	parked := msg.Attempts+1 >= maxAttempts
+	if parked {
+		updates["parked_at"] = time.Now()
+	}
+	err := postgres.DB.Model(&entity.OutboxMessage{}).Where("id = ?", msg.ID).Updates(updates).Error
+	return parked, err
+}
+
+// 查询未发送（不含已搁置）消息的数量和最早一条的写入时间（没有未发送消息时为 nil）
+func GetOutboxLag() (int64, *time.Time, error) {
+	var result struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err := postgres.DB.Model(&entity.OutboxMessage{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("sent_at IS NULL AND parked_at IS NULL").
+		Scan(&result).Error
+	return result.Count, result.Oldest, err
+}
+
+// 统计已搁置（失败次数达到上限，不再自动重试）的消息数量
+func CountParkedOutboxMessages() (int64, error) {
+	var count int64
+	err := postgres.DB.Model(&entity.OutboxMessage{}).
+		Where("sent_at IS NULL AND parked_at IS NOT NULL").
+		Count(&count).Error
+	return count, err
+}
+
+// 删除发送时间早于 before 的消息
+func DeleteSentOutboxMessages(before time.Time) (int64, error) {
+	result := postgres.DB.Where("sent_at < ?", before).Delete(&entity.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,108 @@
+package dao
+
+import (
+	"strconv"
+	"time"
+	"vision/dao/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vision/constants"
+	"vision/models/entity"
+)
+
+// 置顶帖子，已置顶时更新到期时间和操作人
+// 置顶范围内其他未到期的置顶达到 limit 时返回 ErrorPinLimit
+// 统计和写入在同一个事务中，并按置顶范围加事务级咨询锁，避免并发置顶超出上限
+func PinPost(pin *entity.PostPin, limit int64, now time.Time) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))",
+			"post_pin:"+strconv.FormatInt(pin.CommunityID, 10)).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&entity.PostPin{}).
+			Where("community_id = ? AND post_id <> ?", pin.CommunityID, pin.PostID).
+			Where("expires_at IS NULL OR expires_at > ?", now).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count >= limit {
+			return constants.ErrorPinLimit
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "community_id"}, {Name: "post_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"pinned_by", "expires_at", "updated_at"}),
+		}).Create(pin).Error
+	})
+}
+
+// 取消置顶（物理删除，避免软删除的记录占用唯一索引）
+func UnpinPost(communityID, postID int64) error {
+	return postgres.DB.Unscoped().
+		Where("community_id = ? AND post_id = ?", communityID, postID).
+		Delete(&entity.PostPin{}).Error
+}
+
+// 删除帖子的所有置顶记录
+func DeletePostPins(postID int64) error {
+	return postgres.DB.Unscoped().
+		Where("post_id = ?", postID).
+		Delete(&entity.PostPin{}).Error
+}
+
+// 删除已到期的置顶记录
+func DeleteExpiredPins(now time.Time) error {
+	return postgres.DB.Unscoped().
+		Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+		Delete(&entity.PostPin{}).Error
+}
+
+// 查询置顶范围内未到期的置顶帖子ID（按置顶时间倒序，只包含已发布的帖子）
+func GetActivePinnedPostIDs(communityID int64, now time.Time) ([]string, error) {
+	var postIDs []int64
+	err := postgres.DB.Model(&entity.PostPin{}).
+		Joins("JOIN post ON post.id = post_pin.post_id AND post.deleted_at IS NULL").
+		Where("post_pin.community_id = ? AND post.status = ?", communityID, entity.PostStatusPublished).
+		Where("post_pin.expires_at IS NULL OR post_pin.expires_at > ?", now).
+		Order("post_pin.updated_at DESC").
+		Pluck("post_pin.post_id", &postIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(postIDs))
+	for i, id := range postIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return ids, nil
+}
+
+// 添加社区版主，已是版主时忽略
+func AddCommunityModerator(communityID, userID int64) error {
+	moderator := entity.CommunityModerator{
+		CommunityID: communityID,
+		UserID:      userID,
+	}
+	return postgres.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&moderator).Error
+}
+
+// 移除社区版主
+func RemoveCommunityModerator(communityID, userID int64) error {
+	return postgres.DB.Unscoped().
+		Where("community_id = ? AND user_id = ?", communityID, userID).
+		Delete(&entity.CommunityModerator{}).Error
+}
+
+// 检查用户是否为社区版主
+func IsCommunityModerator(communityID, userID int64) (bool, error) {
+	var count int64
+	err := postgres.DB.Model(&entity.CommunityModerator{}).
+		Where("community_id = ? AND user_id = ?", communityID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
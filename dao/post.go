@@ -1,15 +1,18 @@
 package dao
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"vision/dao/postgres"
 	"vision/models/request"
 
 	"vision/constants"
 	"vision/models/entity"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -27,6 +30,19 @@ func CreatePost(p *entity.Post) error {
 	return nil
 }
 
+// 按预先分配的 ID 创建帖子，帖子已存在（包括已删除的帖子）时不重复创建
+// 返回是否新创建了帖子
+func CreatePostIfAbsent(p *entity.Post) (bool, error) {
+	result := postgres.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoNothing: true,
+	}).Create(p)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // 删除帖子
 func DeletePost(id int64) error {
 	// 先删除关联的评论
@@ -34,6 +50,31 @@ func DeletePost(id int64) error {
 		return err
 	}
 
+	// 删除帖子的历史版本
+	if err := postgres.DB.Where("post_id = ?", id).Delete(&entity.PostRevision{}).Error; err != nil {
+		return err
+	}
+
+	// 删除帖子的收藏记录
+	if err := DeletePostBookmarks(id); err != nil {
+		return err
+	}
+
+	// 删除帖子的标签关联
+	if err := DeletePostTags(id); err != nil {
+		return err
+	}
+
+	// 删除帖子的附件记录
+	if err := DeletePostAttachments(id); err != nil {
+		return err
+	}
+
+	// 删除帖子的置顶记录
+	if err := DeletePostPins(id); err != nil {
+		return err
+	}
+
 	// 再删除帖子
 	result := postgres.DB.Delete(&entity.Post{}, id)
 	if result.Error != nil {
@@ -45,6 +86,62 @@ func DeletePost(id int64) error {
 	return nil
 }
 
+// 编辑帖子：在同一个事务中更新帖子内容并保存旧版本
+func UpdatePost(post *entity.Post, content, image string, editorID int64) error {
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		// 先更新帖子内容，revision_count 作为乐观锁，防止并发编辑丢失版本
+		// 并发编辑时只有一个请求能更新成功，失败的请求不会再写入版本记录触发唯一索引冲突
+		now := time.Now()
+		result := tx.Model(&entity.Post{}).
+			Where("id = ? AND revision_count = ?", post.ID, post.RevisionCount).
+			Updates(map[string]interface{}{
+				"content":        content,
+				"image":          image,
+				"edited_at":      now,
+				"revision_count": post.RevisionCount + 1,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return constants.ErrorNotAffectData
+		}
+
+		// 更新成功后保存旧版本
+		revision := &entity.PostRevision{
+			PostID:   post.ID,
+			Version:  post.RevisionCount + 1,
+			Content:  post.Content,
+			Image:    post.Image,
+			EditorID: editorID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		// 绑定新图片对应的附件，旧图片仍被历史版本引用，保留原附件
+		if err := bindImageAttachment(tx, post.ID, post.AuthorID, image); err != nil {
+			return err
+		}
+
+		post.Content = content
+		post.Image = image
+		post.EditedAt = &now
+		post.RevisionCount++
+		return nil
+	})
+}
+
+// 查询帖子的所有历史版本（按版本号倒序）
+func GetPostRevisions(postID int64) ([]*entity.PostRevision, error) {
+	var revisions []*entity.PostRevision
+	result := postgres.DB.
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Find(&revisions)
+	return revisions, result.Error
+}
+
 // 根据帖子id查询帖子详情
 func GetPostById(pid int64) (*entity.Post, error) {
 	var post *entity.Post
@@ -52,6 +149,18 @@ func GetPostById(pid int64) (*entity.Post, error) {
 	return post, result.Error
 }
 
+// 根据id查询已发布的帖子，草稿和定时发布的帖子返回 gorm.ErrRecordNotFound
+func GetPublishedPostById(pid int64) (*entity.Post, error) {
+	var post *entity.Post
+	result := postgres.DB.Scopes(published).Where("id = ?", pid).First(&post)
+	return post, result.Error
+}
+
+// 只查询已发布的帖子，所有对外的列表查询都需要使用
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", entity.PostStatusPublished)
+}
+
 // 根据给定的id列表查询帖子数据
 //func GetPostListByIDs(ids []string) ([]*entity.Post, error) {
 //	var posts []*entity.Post
@@ -115,7 +224,7 @@ func GetPostListByIDs(ids []string) ([]*entity.Post, error) {
 	// 生成的 SQL 类似于: ORDER BY array_position(ARRAY['102','95','101'], id)
 	orderClause := fmt.Sprintf("array_position(ARRAY[%s], id::text)", strings.Join(quotedIDs, ","))
 
-	result := postgres.DB.
+	result := postgres.DB.Scopes(published).
 		Where("id IN ?", ids).
 		Order(orderClause).
 		Find(&posts)
@@ -123,12 +232,65 @@ func GetPostListByIDs(ids []string) ([]*entity.Post, error) {
 	return posts, result.Error
 }
 
+// 按ID升序分批查询指定时间之后发布的帖子（只取排序需要的字段）
+func GetPostsCreatedAfter(since time.Time, lastID int64, limit int) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := postgres.DB.Scopes(published).
+		Select("id", "community_id", "created_at").
+		Where("created_at >= ? AND id > ?", since, lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&posts)
+	return posts, result.Error
+}
+
+// 按ID升序分批查询帖子
+func GetPostBatch(lastID int64, limit int) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := postgres.DB.Scopes(published).
+		Where("id > ?", lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&posts)
+	return posts, result.Error
+}
+
+// 查询最新发布的若干帖子
+func GetRecentPosts(limit int) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := postgres.DB.Scopes(published).
+		Order("id DESC").
+		Limit(limit).
+		Find(&posts)
+	return posts, result.Error
+}
+
+// 根据id列表查询帖子，包括已删除的帖子（只取id、社区、状态和删除时间）
+func GetPostsByIDsUnscoped(ids []int64) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	result := postgres.DB.Unscoped().
+		Select("id", "community_id", "status", "deleted_at").
+		Where("id IN ?", ids).
+		Find(&posts)
+	return posts, result.Error
+}
+
+// 统计用户已发布的帖子数量（不包含草稿和定时发布的帖子）
+func CountUserPublishedPosts(userID int64) (int64, error) {
+	var total int64
+	err := postgres.DB.Model(&entity.Post{}).Scopes(published).Where("author_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
 // 根据userID，分页获取用户发布的帖子列表
 func GetPostListByUserID(userID, page, size int64) ([]*entity.Post, int64, error) {
 	var posts []*entity.Post
 	var total int64
 
-	if err := postgres.DB.Model(&entity.Post{}).Where("author_id = ?", userID).Count(&total).Error; err != nil {
+	if err := postgres.DB.Model(&entity.Post{}).Scopes(published).Where("author_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -136,7 +298,7 @@ func GetPostListByUserID(userID, page, size int64) ([]*entity.Post, int64, error
 	offset := (page - 1) * size
 
 	// 查询二级评论（parent_id 为 commentID）
-	result := postgres.DB.
+	result := postgres.DB.Scopes(published).
 		Where("author_id = ?", userID).
 		Order("created_at DESC"). // 默认按时间倒序排序
 		Limit(int(size)).
@@ -151,7 +313,7 @@ func GetPostListByUserID(userID, page, size int64) ([]*entity.Post, int64, error
 
 // GetPostIDs 根据请求参数查询符合条件的 ID 列表
 func GetPostIDs(p *request.ListRequest) (ids []string, total int64, err error) {
-	db := postgres.DB.Model(&entity.Post{})
+	db := postgres.DB.Model(&entity.Post{}).Scopes(published)
 
 	// 1. 简单的筛选条件（如果有）
 	// if p.CommunityID != 0 {
@@ -192,7 +354,7 @@ func GetPostIDs(p *request.ListRequest) (ids []string, total int64, err error) {
 // GetCommunityPostIDs 根据社区ID查询帖子ID列表
 func GetCommunityPostIDs(p *request.ListRequest, communityID int64) (ids []string, total int64, err error) {
 	// 1. 建立查询模型
-	db := postgres.DB.Model(&entity.Post{}).Where("community_id = ?", communityID)
+	db := postgres.DB.Model(&entity.Post{}).Scopes(published).Where("community_id = ?", communityID)
 
 	// 2. 查询总数
 	if err = db.Count(&total).Error; err != nil {
@@ -222,55 +384,178 @@ func GetCommunityPostIDs(p *request.ListRequest, communityID int64) (ids []strin
 }
 
 func SaveVote(userID int64, postID int64, direction int8) error {
-	// 如果 direction 为 0，通常表示取消投票，我们可以选择删除记录或者标记为0
-	// 这里采用物理删除，保持表数据量较小（或者你可以选择软删除）
-	if direction == 0 {
-		return postgres.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&entity.PostVote{}).Error
-	}
-
-	// 如果是 1 或 -1，则执行 Upsert (有则更新，无则插入)
-	vote := entity.PostVote{
-		UserID:    userID,
-		PostID:    postID,
-		Direction: direction,
-	}
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		// 以共享锁确认帖子投票尚未归档，归档时对帖子加排他锁，保证归档开始后不再写入新的投票
+		var post entity.Post
+		err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Select("id").
+			Where("id = ? AND votes_archived_at IS NULL", postID).
+			First(&post).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return constants.ErrorVoteTimeExpire
+		}
+		if err != nil {
+			return err
+		}
+
+		// 如果 direction 为 0，通常表示取消投票，我们可以选择删除记录或者标记为0
+		// 这里采用物理删除，保持表数据量较小（或者你可以选择软删除）
+		if direction == 0 {
+			return tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&entity.PostVote{}).Error
+		}
+
+		// 如果是 1 或 -1，则执行 Upsert (有则更新，无则插入)
+		vote := entity.PostVote{
+			UserID:    userID,
+			PostID:    postID,
+			Direction: direction,
+		}
+
+		// 使用 GORM 的 Clauses 进行 Upsert
+		// 当 user_id + post_id 冲突时，更新 direction 和 updated_at
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"direction", "updated_at"}),
+		}).Create(&vote).Error
+	})
+}
 
-	// 使用 GORM 的 Clauses 进行 Upsert
-	// 当 user_id + post_id 冲突时，更新 direction 和 updated_at
-	return postgres.DB.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"direction", "updated_at"}),
-	}).Create(&vote).Error
+// 查询发布时间早于 before 且投票尚未归档的帖子
+func GetPostsToArchive(before time.Time, limit int) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := postgres.DB.Scopes(published).
+		Select("id", "community_id", "created_at").
+		Where("created_at < ? AND votes_archived_at IS NULL", before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&posts)
+	return posts, result.Error
 }
 
-// GetUserLikedPostIDs 获取用户点赞的帖子ID列表 (Direction = 1)
-func GetUserLikedPostIDs(p *request.ListRequest, userID int64) (ids []string, total int64, err error) {
-	db := postgres.DB.Model(&entity.PostVote{}).
-		Where("user_id = ? AND direction = ?", userID, 1) // 只查点赞的(direction=1)
+// 归档帖子投票：在同一个事务中标记帖子已归档、补齐投票记录，并冻结帖子的票数和分数，返回归档的赞成/反对票数
+// 先标记归档并持有帖子的行锁，正在进行的投票（SaveVote）提交后才能继续，此后的投票都会被拒绝，
+// 因此票数以数据库中的投票记录为准，不会遗漏读取 redis 快照之后才写入的投票
+// votes 为 redis 中的投票记录，只补齐数据库中没有的记录（早期投票只写入了 redis）
+func ArchivePostVotes(postID int64, votes []*entity.PostVote, score float64) (ups, downs int64, err error) {
+	err = postgres.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Post{}).
+			Where("id = ? AND votes_archived_at IS NULL", postID).
+			Update("votes_archived_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 { // 已被归档
+			return constants.ErrorNotAffectData
+		}
+
+		// 取消投票（direction=0）的记录在 SaveVote 中已删除，不需要补齐
+		var active []*entity.PostVote
+		for _, vote := range votes {
+			if vote.Direction != 0 {
+				active = append(active, vote)
+			}
+		}
+		if len(active) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(active, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		var counts []struct {
+			Direction int8
+			Count     int64
+		}
+		if err := tx.Model(&entity.PostVote{}).
+			Select("direction, COUNT(*) AS count").
+			Where("post_id = ?", postID).
+			Group("direction").
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+		for _, c := range counts {
+			switch c.Direction {
+			case 1:
+				ups = c.Count
+			case -1:
+				downs = c.Count
+			}
+		}
+
+		return tx.Model(&entity.Post{}).Where("id = ?", postID).Updates(map[string]interface{}{
+			"like_count":    ups,
+			"dislike_count": downs,
+			"final_score":   score,
+		}).Error
+	})
+	return ups, downs, err
+}
 
-	// 1. 统计总数
-	if err = db.Count(&total).Error; err != nil {
-		return
+// 查询一批帖子的所有投票记录
+func GetPostVotesByPostIDs(postIDs []int64) ([]*entity.PostVote, error) {
+	var votes []*entity.PostVote
+	if len(postIDs) == 0 {
+		return votes, nil
 	}
-	if total == 0 {
-		return
+	result := postgres.DB.Where("post_id IN ?", postIDs).Find(&votes)
+	return votes, result.Error
+}
+
+// 查询用户对一批帖子的投票方向，返回 postID -> 投票方向（未投票的帖子不在结果中）
+func GetUserPostVoteDirections(userID int64, postIDs []int64) (map[int64]int8, error) {
+	directions := make(map[int64]int8, len(postIDs))
+	if len(postIDs) == 0 {
+		return directions, nil
 	}
 
-	// 2. 排序 (按最后更新时间倒序，即最近点赞的在前)
-	db = db.Order("updated_at DESC")
+	var votes []*entity.PostVote
+	result := postgres.DB.
+		Select("post_id", "direction").
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Find(&votes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, vote := range votes {
+		directions[vote.PostID] = vote.Direction
+	}
+	return directions, nil
+}
 
-	// 3. 分页取 PostID
-	offset := (p.Page - 1) * p.Size
-	var intIDs []int64
-	err = db.Limit(int(p.Size)).Offset(int(offset)).Pluck("post_id", &intIDs).Error
+// 查询用户点赞的已发布帖子及点赞时间（迁移点赞记录时使用）
+func GetUserLikeTimes(userID int64) (map[string]time.Time, error) {
+	var votes []*entity.PostVote
+	err := postgres.DB.Select("post_vote.post_id", "post_vote.updated_at").
+		Joins("JOIN post ON post.id = post_vote.post_id AND post.deleted_at IS NULL AND post.status = ?", entity.PostStatusPublished).
+		Where("post_vote.user_id = ? AND post_vote.direction = ?", userID, 1).
+		Find(&votes).Error
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	// 4. 格式转换
-	ids = make([]string, len(intIDs))
-	for i, v := range intIDs {
-		ids[i] = strconv.FormatInt(v, 10)
+	likeTimes := make(map[string]time.Time, len(votes))
+	for _, vote := range votes {
+		likeTimes[strconv.FormatInt(vote.PostID, 10)] = vote.UpdatedAt
 	}
-	return
+	return likeTimes, nil
+}
+
+// 按用户id升序分批查询有点赞记录的用户id
+func GetLikerUserIDBatch(lastUserID int64, limit int) ([]int64, error) {
+	var userIDs []int64
+	err := postgres.DB.Model(&entity.PostVote{}).
+		Distinct("user_id").
+		Where("direction = ? AND user_id > ?", 1, lastUserID).
+		Order("user_id ASC").
+		Limit(limit).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// 查询点赞过该帖子的用户id列表
+func GetPostLikerIDs(postID int64) ([]int64, error) {
+	var userIDs []int64
+	err := postgres.DB.Model(&entity.PostVote{}).
+		Where("post_id = ? AND direction = ?", postID, 1).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
 }
@@ -45,56 +45,51 @@ func CreateTopComment(commentID, postID int64) error {
 }
 
 // 创建子评论
-func CreateSonComment(rootID, postID int64) error {
+func CreateSonComment(commentID, rootID, postID int64) error {
 	rootIDStr := strconv.FormatInt(rootID, 10)
 	postIDStr := strconv.FormatInt(postID, 10)
 
+	//开启事务
+	pipeline := client.TxPipeline()
+
 	// 在redis中更新评论数（累计+1）
-	client.ZIncrBy(getRedisKey(KeyPostCommentNumZSet), 1, postIDStr)
+	pipeline.ZIncrBy(getRedisKey(KeyPostCommentNumZSet), 1, postIDStr)
 
 	// 在redis中更新子评论数（累计+1）
-	err := client.ZIncrBy(getRedisKey(KeyCommentNumZSet), 1, rootIDStr).Err()
+	pipeline.ZIncrBy(getRedisKey(KeyCommentNumZSet), 1, rootIDStr)
+
+	// 在根评论的子评论分数集合中添加该评论，默认分数为当前时间戳（与顶级评论一致）
+	pipeline.ZAdd(getRedisKey(KeyCommentReplyScoreZSetPF+rootIDStr), redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: strconv.FormatInt(commentID, 10),
+	})
 
+	_, err := pipeline.Exec()
 	return err
 }
 
-// 删除评论
-func DeleteComment(commentID, postID int64, rootID *int64) error {
+// 删除评论后更新 redis：子评论不再随之删除，帖子总评论数只减 1，子评论同时减少根评论的回复数
+// tombstoned 为 true 时评论作为墓碑保留在评论列表中，否则从列表中移除
+func DeleteComment(commentID, postID int64, rootID *int64, tombstoned bool) error {
 	pipeline := client.TxPipeline()
 
 	postIDStr := strconv.FormatInt(postID, 10)
 	commentIDStr := strconv.FormatInt(commentID, 10)
 
-	// 1. 从帖子评论时间集合中删除该评论（一级评论才有效）
-	pipeline.ZRem(getRedisKey(KeyCommentTimeZSetPF+postIDStr), commentIDStr)
+	// 1. 减少帖子总评论数
+	pipeline.ZIncrBy(getRedisKey(KeyPostCommentNumZSet), -1, postIDStr)
 
-	// 2. 从评论分数集合中删除该评论（一级评论才有效）
-	pipeline.ZRem(getRedisKey(KeyCommentScoreZSetPF+postIDStr), commentIDStr)
+	// 2. 如果是子评论，减少根评论的回复数
+	if rootID != nil {
+		pipeline.ZIncrBy(getRedisKey(KeyCommentNumZSet), -1, strconv.FormatInt(*rootID, 10))
+	}
 
-	// 3. 删除该评论的点赞记录
+	// 3. 删除该评论的投票记录
 	pipeline.Del(getRedisKey(KeyCommentVotedZSetPF + commentIDStr))
 
-	// 4. 从评论数集合中删除该评论（一级评论才有效）
-	pipeline.ZRem(getRedisKey(KeyCommentNumZSet), commentIDStr)
-
-	// 5. 如果是顶级评论，减少帖子总评论数（减少：顶级评论的子评论数+1）
-	if rootID == nil {
-		// 查找该评论的子评论数
-		sonCommentNum, err := GetSonCommentNumByIDs([]string{commentIDStr})
-		if err != nil {
-			return err
-		}
-		if len(sonCommentNum) == 0 {
-			return constants.ErrorNoResult
-		}
-
-		// 减少帖子总评论数
-		pipeline.ZIncrBy(getRedisKey(KeyPostCommentNumZSet), -1-sonCommentNum[0], postIDStr)
-	} else {
-		// 6. 如果是子评论，减少帖子总评论数并且减少父评论的子评论数
-		rootIDStr := strconv.FormatInt(*rootID, 10)
-		pipeline.ZIncrBy(getRedisKey(KeyPostCommentNumZSet), -1, postIDStr)
-		pipeline.ZIncrBy(getRedisKey(KeyCommentNumZSet), -1, rootIDStr)
+	// 4. 没有保留为墓碑时，从评论列表中移除
+	if !tombstoned {
+		removeComments(pipeline, postIDStr, CommentRef{ID: commentID, RootID: rootID})
 	}
 
 	// 执行 Redis 事务
@@ -102,8 +97,40 @@ func DeleteComment(commentID, postID int64, rootID *int64) error {
 	return err
 }
 
+// 评论的id和根评论id（顶级评论的根评论id为nil）
+type CommentRef struct {
+	ID     int64
+	RootID *int64
+}
+
+// 从评论列表中移除已没有回复而被删除的墓碑评论（计数在评论成为墓碑时已减少）
+func RemoveCommentTombstones(postID int64, comments []CommentRef) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	pipeline := client.TxPipeline()
+	removeComments(pipeline, strconv.FormatInt(postID, 10), comments...)
+	_, err := pipeline.Exec()
+	return err
+}
+
+// 从评论列表中移除评论：顶级评论从帖子的评论时间、评论分数集合和回复数集合中移除，子评论从根评论的子评论分数集合中移除
+func removeComments(pipeline redis.Pipeliner, postIDStr string, comments ...CommentRef) {
+	for _, comment := range comments {
+		commentIDStr := strconv.FormatInt(comment.ID, 10)
+		if comment.RootID != nil {
+			pipeline.ZRem(getRedisKey(KeyCommentReplyScoreZSetPF+strconv.FormatInt(*comment.RootID, 10)), commentIDStr)
+			continue
+		}
+		pipeline.ZRem(getRedisKey(KeyCommentTimeZSetPF+postIDStr), commentIDStr)
+		pipeline.ZRem(getRedisKey(KeyCommentScoreZSetPF+postIDStr), commentIDStr)
+		pipeline.ZRem(getRedisKey(KeyCommentNumZSet), commentIDStr)
+		pipeline.Del(getRedisKey(KeyCommentReplyScoreZSetPF + commentIDStr))
+	}
+}
+
 // 根据排序方式和索引范围，查询顶级评论id列表
-func GetTopCommentIDsInOrder(p *request.ListRequest, postID int64) ([]string, int64, error) {
+func GetTopCommentIDsInOrder(p *request.ListRequest, postID int64) ([]string, int64, string, error) {
 	//从redis中获取id
 	//1.根据用户请求中携带的order参数（排序方式）确定要查询的redis key
 	key := getRedisKey(KeyCommentTimeZSetPF + strconv.Itoa(int(postID)))
@@ -111,38 +138,83 @@ func GetTopCommentIDsInOrder(p *request.ListRequest, postID int64) ([]string, in
 		key = getRedisKey(KeyCommentScoreZSetPF) + strconv.Itoa(int(postID))
 	}
 
-	return getIDsFormKey(key, p.Page, p.Size)
+	return getIDsFormKey(key, p)
 }
 
-// 根据ids列表查询每条评论的赞成票数据
-func GetCommentVoteDataByIDs(ids []string) (data []int64, err error) {
+// 按投票分数查询顶级评论的子评论id列表
+func GetSonCommentIDsByScore(p *request.ListRequest, rootID int64) ([]string, int64, string, error) {
+	return getIDsFormKey(getRedisKey(KeyCommentReplyScoreZSetPF+strconv.FormatInt(rootID, 10)), p)
+}
+
+// 查询根评论的子评论分数集合中的评论数
+func CountSonCommentScores(rootID int64) (int64, error) {
+	return client.ZCard(getRedisKey(KeyCommentReplyScoreZSetPF + strconv.FormatInt(rootID, 10))).Result()
+}
+
+// 将缺少的子评论以发布时间为默认分数补入根评论的子评论分数集合，已有的评论保留原分数
+func AddSonCommentScores(rootID int64, createdAt map[int64]time.Time) error {
+	if len(createdAt) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, 0, len(createdAt))
+	for commentID, t := range createdAt {
+		members = append(members, redis.Z{
+			Score:  float64(t.Unix()),
+			Member: strconv.FormatInt(commentID, 10),
+		})
+	}
+	return client.ZAddNX(getRedisKey(KeyCommentReplyScoreZSetPF+strconv.FormatInt(rootID, 10)), members...).Err()
+}
+
+// 根据ids列表批量查询每条评论的投票数据，userID 为空时不查询当前用户的投票方向
+func GetCommentVoteDataByIDs(ids []string, userID string) ([]VoteData, error) {
 	// 使用 pipeline 批量执行 Redis 命令
 	pipeline := client.Pipeline()
-	var cmds []redis.Cmder
+	upCmds := make([]*redis.IntCmd, len(ids))
+	downCmds := make([]*redis.IntCmd, len(ids))
+	directionCmds := make([]*redis.FloatCmd, len(ids))
 
-	// 将所有 ZCount 命令添加到 pipeline 中
-	for _, id := range ids {
+	for i, id := range ids {
 		key := getRedisKey(KeyCommentVotedZSetPF + id)
-		// 统计该帖子的赞成票数（1代表赞成票）
-		cmds = append(cmds, pipeline.ZCount(key, "1", "1"))
+		upCmds[i] = pipeline.ZCount(key, "1", "1")
+		downCmds[i] = pipeline.ZCount(key, "-1", "-1")
+		if userID != "" {
+			directionCmds[i] = pipeline.ZScore(key, userID)
+		}
 	}
 
-	// 执行所有命令
-	_, err = pipeline.Exec()
-	if err != nil {
+	// 未投票的用户 ZScore 会返回 redis.Nil，不视为错误
+	if _, err := pipeline.Exec(); err != nil && !errors.Is(err, redis.Nil) {
 		return nil, err
 	}
 
-	// 处理返回的结果
-	for _, cmd := range cmds {
-		// 类型断言为 redis.IntCmd
-		voteCmd := cmd.(*redis.IntCmd)
-		data = append(data, voteCmd.Val()) // 获取赞成票数
+	data := make([]VoteData, len(ids))
+	for i := range ids {
+		data[i].Ups = upCmds[i].Val()
+		data[i].Downs = downCmds[i].Val()
+		if directionCmds[i] != nil {
+			data[i].Direction = int8(directionCmds[i].Val())
+		}
 	}
-
 	return data, nil
 }
 
+// 根据ids列表批量查询每条评论的赞成票数和反对票数
+func GetCommentVoteCountsByIDs(ids []string) (ups, downs []int64, err error) {
+	data, err := GetCommentVoteDataByIDs(ids, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ups = make([]int64, len(ids))
+	downs = make([]int64, len(ids))
+	for i := range data {
+		ups[i] = data[i].Ups
+		downs[i] = data[i].Downs
+	}
+	return ups, downs, nil
+}
+
 // 根据帖子id列表查询帖子的评论数
 func GetCommentNumByIDs(ids []string) ([]float64, error) {
 	// 使用 pipeline 批量执行 Redis 命令
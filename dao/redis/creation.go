@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+)
+
+// 异步发帖状态的保留时间，客户端应在此时间内轮询
+const postCreationStatusTTL = 24 * time.Hour
+
+// 异步发帖状态
+type PostCreationStatus struct {
+	Status   string // pending / created / failed
+	AuthorID int64
+	Reason   string // 失败原因
+}
+
+func postCreationKey(postID int64) string {
+	return getRedisKey(KeyPostCreationHashPF + strconv.FormatInt(postID, 10))
+}
+
+// 记录异步发帖状态
+func SetPostCreationStatus(postID, authorID int64, status, reason string) error {
+	key := postCreationKey(postID)
+	pipeline := client.TxPipeline()
+	pipeline.HMSet(key, map[string]interface{}{
+		"status":    status,
+		"author_id": authorID,
+		"reason":    reason,
+	})
+	pipeline.Expire(key, postCreationStatusTTL)
+	_, err := pipeline.Exec()
+	return err
+}
+
+// 查询异步发帖状态，没有记录（未通过异步发帖或已过期）时返回 nil
+func GetPostCreationStatus(postID int64) (*PostCreationStatus, error) {
+	fields, err := client.HGetAll(postCreationKey(postID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	authorID, _ := strconv.ParseInt(fields["author_id"], 10, 64)
+	return &PostCreationStatus{
+		Status:   fields["status"],
+		AuthorID: authorID,
+		Reason:   fields["reason"],
+	}, nil
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-redis/redis"
+
+	"vision/constants"
+	"vision/pkg/cursor"
+)
+
+// 按游标查询有序集合中排在游标之后的 size 个成员（按分数从大到小），返回成员列表和下一页的游标
+func getIDsAfterCursor(key, token string, size int64) ([]string, string, error) {
+	c, err := cursor.Decode(token)
+	if err != nil {
+		return nil, "", constants.ErrorInvalidParam
+	}
+
+	// 查询游标元素当前的分数和排名
+	pipeline := client.Pipeline()
+	scoreCmd := pipeline.ZScore(key, c.Member)
+	rankCmd := pipeline.ZRevRank(key, c.Member)
+	// 游标元素已被删除时返回 redis.Nil，不视为错误
+	if _, err := pipeline.Exec(); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, "", err
+	}
+
+	// 多查一个元素，用来判断是否还有下一页
+	var result []redis.Z
+	if scoreCmd.Err() == nil && scoreCmd.Val() == c.Score {
+		// 游标元素仍在原位置，从它的排名之后继续查询，分数相同的元素也不会遗漏
+		rank := rankCmd.Val()
+		result, err = client.ZRevRangeWithScores(key, rank+1, rank+size+1).Result()
+	} else {
+		// 游标元素已被删除或分数已变化（例如热度更新），查询分数严格小于游标的元素
+		result, err = client.ZRevRangeByScoreWithScores(key, redis.ZRangeBy{
+			Max:   "(" + strconv.FormatFloat(c.Score, 'f', -1, 64),
+			Min:   "-inf",
+			Count: size + 1,
+		}).Result()
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	hasMore := int64(len(result)) > size
+	if hasMore {
+		result = result[:size]
+	}
+	ids, next := membersWithCursor(result, hasMore)
+	return ids, next, nil
+}
+
+// 取出查询结果中的成员，还有下一页时用最后一个元素生成游标
+func membersWithCursor(result []redis.Z, hasMore bool) ([]string, string) {
+	ids := make([]string, len(result))
+	for i, z := range result {
+		ids[i] = z.Member.(string)
+	}
+	if !hasMore || len(result) == 0 {
+		return ids, ""
+	}
+	last := result[len(result)-1]
+	return ids, cursor.Encode(last.Score, ids[len(ids)-1])
+}
+
+// 生成有序集合中指定成员的游标，成员不存在时返回空字符串
+func getCursorOf(key, member string) (string, error) {
+	score, err := client.ZScore(key, member).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cursor.Encode(score, member), nil
+}
+
+// 生成按时间排序的帖子列表中指定帖子的游标（社区帖子列表按时间排序时使用相同的分数）
+func GetPostTimeCursor(postID string) (string, error) {
+	return getCursorOf(getRedisKey(KeyPostTimeZSet), postID)
+}
@@ -10,6 +10,23 @@ const (
 	KeyPostVotedZSetPF = "post:voted:" // zset; key=post:voted:{postID}, 成员=userID, 分数=1(点赞) / -1(踩)
 	KeyCommunitySetPF  = "community:"  // set; key=community:{communityID}, 成员=postID（社区帖子集合）
 
+	// 投票归档相关（帖子发布超过一周后，post:voted:{postID} 迁移到数据库，只保留计数）
+	KeyPostArchivedUpHash   = "post:archived:up"   // hash; key=post:archived:up, 字段=postID, 值=归档时的赞成票数
+	KeyPostArchivedDownHash = "post:archived:down" // hash; key=post:archived:down, 字段=postID, 值=归档时的反对票数
+
+	// 热度排序相关（启用 reddit / hackernews 排序算法时维护）
+	KeyCommunityHotZSetPF = "community:hot:" // zset; key=community:hot:{communityID}, 成员=postID, 分数=热度分数
+
+	// 异步发帖相关（启用 kafka 异步发帖时维护）
+	KeyPostCreationHashPF = "post:creation:" // hash; key=post:creation:{postID}, 字段=status/author_id/reason, 一天后过期
+
+	// 话题标签相关
+	KeyPostTagsSetPF   = "post:tags:"   // set; key=post:tags:{postID}, 成员=标签名
+	KeyTagTimeZSetPF   = "tag:time:"    // zset; key=tag:time:{tag}, 成员=postID, 分数=发帖时间
+	KeyTagScoreZSetPF  = "tag:score:"   // zset; key=tag:score:{tag}, 成员=postID, 分数=热度分数（与 post:score 同步）
+	KeyTagTrendZSetPF  = "tag:trend:"   // zset; key=tag:trend:{yyyyMMddHH}, 成员=标签名, 分数=该小时内使用次数
+	KeyTagTrendingZSet = "tag:trending" // zset; 临时 key，合并最近若干小时的 tag:trend:{hour}
+
 	// 针对高并发单独维护计数的key
 	KeyPostCommentNumZSet = "post:comment_num" // zset; key=post:comment_num, 成员=postID, 分数=总评论数
 	KeyCommentNumZSet     = "comment:num"      // zset; key=comment:num, 成员=commentID, 分数=子评论数
@@ -18,10 +35,14 @@ const (
 	KeyCommentTimeZSetPF  = "comment:time:"  // zset; key=comment:time:{postID}, 成员=commentID, 分数=评论时间
 	KeyCommentScoreZSetPF = "comment:score:" // zset; key=comment:score:{postID}, 成员=commentID, 分数=评论投票分数
 	KeyCommentVotedZSetPF = "comment:voted:" // zset; key=comment:voted:{commentID}, 成员=userID, 分数=1(点赞) / -1(踩)
+	// zset; key=comment:reply_score:{rootID}, 成员=子评论id, 分数=子评论投票分数（与 comment:score: 相同，初始为发布时间）
+	KeyCommentReplyScoreZSetPF = "comment:reply_score:"
 
 	// 用户点赞相关
-	KeyUserLikedPostsSetPF    = "user_liked:posts:"    // set; key=post:voted:{userID}, 成员=postID
-	KeyUserLikedCommentsSetPF = "user_liked:comments:" // set; key=post:voted:{userID}, 成员=commentID
+	KeyUserLikedPostsZSetPF   = "user:liked:posts:"    // zset; key=user:liked:posts:{userID}, 成员=postID, 分数=点赞时间
+	KeyUserLikedPostsSetPF    = "user_liked:posts:"    // set; 旧版本的点赞帖子集合，已由 user:liked:posts:{userID} 替代，启动时迁移
+	KeyUserLikedCommentsSetPF = "user_liked:comments:" // set; key=user_liked:comments:{userID}, 成员=当前点赞（投赞成票）的commentID
+	KeyUserLikesMigrated      = "migrate:user_likes"   // string; 点赞有序集合已根据数据库投票记录迁移完成的标记
 )
 
 func getRedisKey(key string) string {
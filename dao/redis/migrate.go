@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// 扫描旧版本的点赞帖子集合（user_liked:posts:{userID}），返回需要迁移的用户id列表
+func GetLegacyLikedUserIDs() ([]int64, error) {
+	prefix := getRedisKey(KeyUserLikedPostsSetPF)
+
+	var userIDs []int64
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(cursor, prefix+"*", 500).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			userID, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+			if err != nil { // 不是 user_liked:posts:{userID} 格式的 key，跳过
+				continue
+			}
+			userIDs = append(userIDs, userID)
+		}
+		if next == 0 {
+			return userIDs, nil
+		}
+		cursor = next
+	}
+}
+
+// 查询旧版本点赞集合中的帖子id
+func GetLegacyLikedPostIDs(userID int64) ([]string, error) {
+	return client.SMembers(getRedisKey(KeyUserLikedPostsSetPF + strconv.FormatInt(userID, 10))).Result()
+}
+
+// 把用户的点赞记录写入点赞有序集合（likedAt 为 postID -> 点赞时间），并删除旧版本的点赞集合（没有旧集合时忽略）
+// 已存在的点赞记录保持不变，迁移期间产生的新点赞不会被覆盖
+func MigrateLegacyLikedPosts(userID int64, likedAt map[string]float64) error {
+	userIDStr := strconv.FormatInt(userID, 10)
+
+	pipeline := client.TxPipeline()
+	if len(likedAt) > 0 {
+		members := make([]redis.Z, 0, len(likedAt))
+		for postID, score := range likedAt {
+			members = append(members, redis.Z{Score: score, Member: postID})
+		}
+		pipeline.ZAddNX(getRedisKey(KeyUserLikedPostsZSetPF+userIDStr), members...)
+	}
+	pipeline.Del(getRedisKey(KeyUserLikedPostsSetPF + userIDStr))
+	_, err := pipeline.Exec()
+	return err
+}
+
+// 从用户的点赞有序集合中移除帖子
+func RemoveUserLikedPosts(userID int64, postIDs []string) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = id
+	}
+	return client.ZRem(getRedisKey(KeyUserLikedPostsZSetPF+strconv.FormatInt(userID, 10)), members...).Err()
+}
+
+// 点赞记录是否已迁移完成
+func IsUserLikesMigrated() (bool, error) {
+	n, err := client.Exists(getRedisKey(KeyUserLikesMigrated)).Result()
+	return n > 0, err
+}
+
+// 标记点赞记录迁移完成
+func SetUserLikesMigrated() error {
+	return client.Set(getRedisKey(KeyUserLikesMigrated), time.Now().Unix(), 0).Err()
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"errors"
 	"strconv"
 	"time"
 
@@ -54,6 +55,9 @@ func DeletePost(postID int64, communityID int64) error {
 	// 查询出此帖子所有一级评论的id
 	commentIDs, _ := client.ZRange(getRedisKey(KeyCommentTimeZSetPF+postIDStr), 0, -1).Result()
 
+	// 查询出此帖子的所有标签
+	tags, _ := client.SMembers(getRedisKey(KeyPostTagsSetPF + postIDStr)).Result()
+
 	// 从时间排序集合删除
 	pipeline.ZRem(getRedisKey(KeyPostTimeZSet), postIDStr) // 从 zSet 中移除指定成员
 
@@ -63,9 +67,23 @@ func DeletePost(postID int64, communityID int64) error {
 	// 删除帖子点赞记录
 	pipeline.Del(getRedisKey(KeyPostVotedZSetPF + postIDStr)) // 删除整个 key
 
+	// 删除帖子的归档投票计数
+	pipeline.HDel(getRedisKey(KeyPostArchivedUpHash), postIDStr)
+	pipeline.HDel(getRedisKey(KeyPostArchivedDownHash), postIDStr)
+
 	// 从社区帖子集合删除
 	pipeline.SRem(getRedisKey(KeyCommunitySetPF+communityIDStr), postIDStr) // 从 set 中移除指定成员
 
+	// 从社区热度集合删除
+	pipeline.ZRem(getRedisKey(KeyCommunityHotZSetPF+communityIDStr), postIDStr)
+
+	// 从标签的时间和热度集合删除
+	for _, tag := range tags {
+		pipeline.ZRem(getRedisKey(KeyTagTimeZSetPF+tag), postIDStr)
+		pipeline.ZRem(getRedisKey(KeyTagScoreZSetPF+tag), postIDStr)
+	}
+	pipeline.Del(getRedisKey(KeyPostTagsSetPF + postIDStr))
+
 	// 删除帖子评论数记录
 	pipeline.ZRem(getRedisKey(KeyPostCommentNumZSet), postIDStr)
 
@@ -78,9 +96,11 @@ func DeletePost(postID int64, communityID int64) error {
 	// 处理该帖子下所有评论的删除
 	if len(commentIDs) > 0 {
 		// 删除该帖子下所有评论的点赞记录，拼凑出需要删除的key的列表
-		keysToDelete := make([]string, len(commentIDs))
-		for i, commentID := range commentIDs {
-			keysToDelete[i] = getRedisKey(KeyCommentVotedZSetPF + commentID)
+		keysToDelete := make([]string, 0, 2*len(commentIDs))
+		for _, commentID := range commentIDs {
+			keysToDelete = append(keysToDelete,
+				getRedisKey(KeyCommentVotedZSetPF+commentID),
+				getRedisKey(KeyCommentReplyScoreZSetPF+commentID)) // 一级评论下子评论的分数集合
 		}
 		pipeline.Del(keysToDelete...) // 一次删除多个 key，提高性能
 
@@ -97,65 +117,123 @@ func DeletePost(postID int64, communityID int64) error {
 	return err
 }
 
-// 根据键名和索引，分页id列表，返回列表和总数（工具函数）
-func getIDsFormKey(key string, page, size int64) ([]string, int64, error) {
+// 根据键名和索引，分页id列表，返回列表、总数和下一页的游标（工具函数）
+// 请求携带游标时从游标位置之后查询，否则按页码查询；没有更多数据时游标为空
+func getIDsFormKey(key string, p *request.ListRequest) ([]string, int64, string, error) {
 	// 查询总数
 	totalCount, err := client.ZCard(key).Result()
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
+	}
+
+	if p.Cursor != "" {
+		ids, next, err := getIDsAfterCursor(key, p.Cursor, p.Size)
+		return ids, totalCount, next, err
 	}
 
 	// 进行分页查询
-	start := (page - 1) * size
-	end := start + size - 1
+	start := (p.Page - 1) * p.Size
+	end := start + p.Size - 1
 
 	// ZRevRange 按分数从大到小查询指定数量的元素
-	result, err := client.ZRevRange(key, start, end).Result()
-	return result, totalCount, err
+	result, err := client.ZRevRangeWithScores(key, start, end).Result()
+	if err != nil {
+		return nil, 0, "", err
+	}
+	ids, next := membersWithCursor(result, start+int64(len(result)) < totalCount)
+	return ids, totalCount, next, nil
 }
 
 // 根据排序方式和索引，查询id列表
-func GetPostIDsInOrder(p *request.ListRequest) ([]string, int64, error) {
+func GetPostIDsInOrder(p *request.ListRequest) ([]string, int64, string, error) {
 	// 根据用户请求中携带的 order 参数（排序方式）确定要查询的 redis key
 	key := getRedisKey(KeyPostTimeZSet)
 	if p.Order == constants.OrderScore {
 		key = getRedisKey(KeyPostScoreZSet)
 	}
 
-	return getIDsFormKey(key, p.Page, p.Size)
+	return getIDsFormKey(key, p)
+}
+
+// 帖子或评论的投票数据
+type VoteData struct {
+	Ups       int64 // 赞成票数
+	Downs     int64 // 反对票数
+	Direction int8  // 当前用户的投票方向：1 赞成 / 0 未投票 / -1 反对
+	Archived  bool  // 投票记录是否已归档到数据库（只有帖子会归档）
 }
 
-// 根据ids列表查询每篇帖子的投赞成票的数据
-func GetPostVoteDataByIDs(ids []string) (data []int64, err error) {
+// 净得票数（赞成票数 - 反对票数）
+func (d VoteData) Score() int64 {
+	return d.Ups - d.Downs
+}
+
+// 根据ids列表批量查询每篇帖子的投票数据，userID 为空时不查询当前用户的投票方向
+// 已归档的帖子（发布超过一周）投票记录已迁移到数据库，票数从归档计数中读取，当前用户的投票方向需要到数据库查询
+func GetPostVoteDataByIDs(ids []string, userID string) ([]VoteData, error) {
 	// 使用 pipeline 批量执行 Redis 命令
 	pipeline := client.Pipeline()
-	var cmds []redis.Cmder
+	upCmds := make([]*redis.IntCmd, len(ids))
+	downCmds := make([]*redis.IntCmd, len(ids))
+	archivedUpCmds := make([]*redis.StringCmd, len(ids))
+	archivedDownCmds := make([]*redis.StringCmd, len(ids))
+	directionCmds := make([]*redis.FloatCmd, len(ids))
 
-	// 将所有 ZCount 命令添加到 pipeline 中
-	for _, id := range ids {
+	for i, id := range ids {
 		key := getRedisKey(KeyPostVotedZSetPF + id)
-		// 统计该帖子的赞成票数
-		cmds = append(cmds, pipeline.ZCount(key, "1", "1"))
+		upCmds[i] = pipeline.ZCount(key, "1", "1")
+		downCmds[i] = pipeline.ZCount(key, "-1", "-1")
+		archivedUpCmds[i] = pipeline.HGet(getRedisKey(KeyPostArchivedUpHash), id)
+		archivedDownCmds[i] = pipeline.HGet(getRedisKey(KeyPostArchivedDownHash), id)
+		if userID != "" {
+			directionCmds[i] = pipeline.ZScore(key, userID)
+		}
 	}
 
-	// 执行所有命令
-	_, err = pipeline.Exec()
-	if err != nil {
+	// 未归档的帖子 HGet、未投票的用户 ZScore 会返回 redis.Nil，不视为错误
+	if _, err := pipeline.Exec(); err != nil && !errors.Is(err, redis.Nil) {
 		return nil, err
 	}
 
-	// 处理结果
-	for _, cmd := range cmds {
-		// 类型断言为 redis.IntCmd
-		voteCmd := cmd.(*redis.IntCmd)
-		data = append(data, voteCmd.Val()) // 获取票数并保存
-	}
+	data := make([]VoteData, len(ids))
+	for i := range ids {
+		data[i].Ups = upCmds[i].Val()
+		data[i].Downs = downCmds[i].Val()
+		if directionCmds[i] != nil {
+			data[i].Direction = int8(directionCmds[i].Val())
+		}
 
+		// 如果帖子已归档，以归档计数为准
+		if n, err := archivedUpCmds[i].Int64(); err == nil {
+			data[i].Ups = n
+			data[i].Archived = true
+		}
+		if n, err := archivedDownCmds[i].Int64(); err == nil {
+			data[i].Downs = n
+			data[i].Archived = true
+		}
+	}
 	return data, nil
 }
 
+// 根据ids列表批量查询每篇帖子的赞成票数和反对票数
+func GetPostVoteCountsByIDs(ids []string) (ups, downs []int64, err error) {
+	data, err := GetPostVoteDataByIDs(ids, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ups = make([]int64, len(ids))
+	downs = make([]int64, len(ids))
+	for i := range data {
+		ups[i] = data[i].Ups
+		downs[i] = data[i].Downs
+	}
+	return ups, downs, nil
+}
+
 // 根据社区id查询该社区下的帖子id列表
-func GetCommunityPostIDsInOrder(p *request.ListRequest, communityID int64) (ids []string, total int64, err error) {
+func GetCommunityPostIDsInOrder(p *request.ListRequest, communityID int64) (ids []string, total int64, next string, err error) {
 	//根据指定的排序方式，确定要操作的redis中的key
 	//orderKey指定排序方式的键名，按时间排序则是KeyPostTimeZSet，按分数排序则是KeyPostScoreZSet
 	orderKey := getRedisKey(KeyPostTimeZSet)
@@ -188,56 +266,11 @@ func GetCommunityPostIDsInOrder(p *request.ListRequest, communityID int64) (ids
 	}
 
 	//查询指定索引范围的id列表
-	return getIDsFormKey(key, p.Page, p.Size)
+	return getIDsFormKey(key, p)
 }
 
-// 根据用户id查询用户点赞过的帖子id列表
-func GetUserLikeIDsInOrder(userID int64, listRequest *request.ListRequest) ([]string, int64, error) {
-	// 获取 user_liked:posts:{userID} 中的所有帖子ID
-	likedPostsKey := getRedisKey(KeyUserLikedPostsSetPF) + strconv.Itoa(int(userID))
-
-	// 获取有效的帖子ID
-	validPostIDsKey := getRedisKey(KeyPostTimeZSet)
-
-	// 获取 zSet 中所有的有效帖子ID
-	validPostIDs, err := client.ZRange(validPostIDsKey, 0, -1).Result()
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// 存储交集的帖子ID
-	var intersection []string
-
-	// 循环检查有效帖子ID是否在用户点赞的帖子集合中
-	for _, postID := range validPostIDs {
-		// 检查用户是否点赞了该帖子
-		isMember, err := client.SIsMember(likedPostsKey, postID).Result()
-		if err != nil {
-			return nil, 0, err
-		}
-		if isMember {
-			// 如果点赞过，添加到交集结果中
-			intersection = append(intersection, postID)
-		}
-	}
-
-	// 获取交集结果的总数
-	totalCount := int64(len(intersection))
-
-	// 如果分页请求存在，按分页要求返回数据
-	start := (listRequest.Page - 1) * listRequest.Size
-	end := start + listRequest.Size
-
-	// 确保分页范围不超过交集结果的长度
-	if start >= totalCount {
-		return nil, totalCount, nil // 页码超出范围，返回空切片
-	}
-	if end > totalCount {
-		end = totalCount
-	}
-
-	// 返回分页后的结果
-	paginatedPosts := intersection[start:end]
-
-	return paginatedPosts, totalCount, nil
+// 根据用户id分页查询用户点赞过的帖子id列表（按点赞时间倒序）
+func GetUserLikeIDsInOrder(userID int64, listRequest *request.ListRequest) ([]string, int64, string, error) {
+	key := getRedisKey(KeyUserLikedPostsZSetPF + strconv.FormatInt(userID, 10))
+	return getIDsFormKey(key, listRequest)
 }
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"strconv"
+
+	"github.com/go-redis/redis"
+
+	"vision/models/request"
+)
+
+// PostScore 单个帖子的热度分数
+type PostScore struct {
+	PostID      int64
+	CommunityID int64
+	Score       float64
+}
+
+// 批量写入帖子热度分数，同时更新全站热度集合、社区热度集合和标签热度集合
+func SetPostScores(scores []PostScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	postIDs := make([]string, len(scores))
+	for i, s := range scores {
+		postIDs[i] = strconv.FormatInt(s.PostID, 10)
+	}
+	tags, err := getPostTags(postIDs)
+	if err != nil {
+		return err
+	}
+
+	pipeline := client.Pipeline()
+	for _, s := range scores {
+		postIDStr := strconv.FormatInt(s.PostID, 10)
+		communityIDStr := strconv.FormatInt(s.CommunityID, 10)
+
+		pipeline.ZAdd(getRedisKey(KeyPostScoreZSet), redis.Z{
+			Score:  s.Score,
+			Member: postIDStr,
+		})
+		pipeline.ZAdd(getRedisKey(KeyCommunityHotZSetPF+communityIDStr), redis.Z{
+			Score:  s.Score,
+			Member: postIDStr,
+		})
+		for _, tag := range tags[postIDStr] {
+			pipeline.ZAdd(getRedisKey(KeyTagScoreZSetPF+tag), redis.Z{
+				Score:  s.Score,
+				Member: postIDStr,
+			})
+		}
+	}
+
+	_, err = pipeline.Exec()
+	return err
+}
+
+// 根据社区热度集合分页查询帖子id列表
+func GetCommunityHotPostIDs(p *request.ListRequest, communityID int64) ([]string, int64, string, error) {
+	key := getRedisKey(KeyCommunityHotZSetPF + strconv.FormatInt(communityID, 10))
+	return getIDsFormKey(key, p)
+}
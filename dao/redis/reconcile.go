@@ -0,0 +1,270 @@
+package redis
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+// PostRef 帖子ID及其所属社区
+type PostRef struct {
+	ID          int64
+	CommunityID int64
+}
+
+// PostState 帖子在 redis 中的状态
+type PostState struct {
+	InTime      bool    // 是否在 post:time 中
+	InScore     bool    // 是否在 post:score 中
+	Score       float64 // post:score 中的分数
+	InCommunity bool    // 是否在 community:{communityID} 中
+	HasCount    bool    // 是否在 post:comment_num 中
+	CommentNum  float64 // post:comment_num 中的评论数
+}
+
+// CommentState 顶级评论在 redis 中的状态
+type CommentState struct {
+	InTime   bool    // 是否在 comment:time:{postID} 中
+	InScore  bool    // 是否在 comment:score:{postID} 中
+	HasCount bool    // 是否在 comment:num 中
+	ReplyNum float64 // comment:num 中的子评论数
+}
+
+// 批量查询帖子在 redis 中的状态
+func GetPostStates(posts []PostRef) ([]PostState, error) {
+	pipeline := client.Pipeline()
+	timeCmds := make([]*redis.FloatCmd, len(posts))
+	scoreCmds := make([]*redis.FloatCmd, len(posts))
+	communityCmds := make([]*redis.BoolCmd, len(posts))
+	countCmds := make([]*redis.FloatCmd, len(posts))
+
+	for i, post := range posts {
+		postIDStr := strconv.FormatInt(post.ID, 10)
+		timeCmds[i] = pipeline.ZScore(getRedisKey(KeyPostTimeZSet), postIDStr)
+		scoreCmds[i] = pipeline.ZScore(getRedisKey(KeyPostScoreZSet), postIDStr)
+		communityCmds[i] = pipeline.SIsMember(getRedisKey(KeyCommunitySetPF+strconv.FormatInt(post.CommunityID, 10)), postIDStr)
+		countCmds[i] = pipeline.ZScore(getRedisKey(KeyPostCommentNumZSet), postIDStr)
+	}
+
+	// 成员不存在时 ZScore 返回 redis.Nil，不视为错误
+	if _, err := pipeline.Exec(); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	states := make([]PostState, len(posts))
+	for i := range posts {
+		states[i] = PostState{
+			InTime:      timeCmds[i].Err() == nil,
+			InScore:     scoreCmds[i].Err() == nil,
+			Score:       scoreCmds[i].Val(),
+			InCommunity: communityCmds[i].Val(),
+			HasCount:    countCmds[i].Err() == nil,
+			CommentNum:  countCmds[i].Val(),
+		}
+	}
+	return states, nil
+}
+
+// PostTagState 帖子的一个标签在 redis 中的状态
+type PostTagState struct {
+	InPostTags bool // 是否在 post:tags:{postID} 中
+	InTime     bool // 帖子是否在 tag:time:{tag} 中
+	InScore    bool // 帖子是否在 tag:score:{tag} 中
+}
+
+// 批量查询帖子的各个标签在 redis 中的状态
+func GetPostTagStates(postID int64, tags []string) ([]PostTagState, error) {
+	postIDStr := strconv.FormatInt(postID, 10)
+
+	pipeline := client.Pipeline()
+	memberCmds := make([]*redis.BoolCmd, len(tags))
+	timeCmds := make([]*redis.FloatCmd, len(tags))
+	scoreCmds := make([]*redis.FloatCmd, len(tags))
+	for i, tag := range tags {
+		memberCmds[i] = pipeline.SIsMember(getRedisKey(KeyPostTagsSetPF+postIDStr), tag)
+		timeCmds[i] = pipeline.ZScore(getRedisKey(KeyTagTimeZSetPF+tag), postIDStr)
+		scoreCmds[i] = pipeline.ZScore(getRedisKey(KeyTagScoreZSetPF+tag), postIDStr)
+	}
+
+	if _, err := pipeline.Exec(); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	states := make([]PostTagState, len(tags))
+	for i := range tags {
+		states[i] = PostTagState{
+			InPostTags: memberCmds[i].Val(),
+			InTime:     timeCmds[i].Err() == nil,
+			InScore:    scoreCmds[i].Err() == nil,
+		}
+	}
+	return states, nil
+}
+
+// 批量查询同一帖子下顶级评论在 redis 中的状态
+func GetTopCommentStates(postID int64, commentIDs []string) ([]CommentState, error) {
+	postIDStr := strconv.FormatInt(postID, 10)
+
+	pipeline := client.Pipeline()
+	timeCmds := make([]*redis.FloatCmd, len(commentIDs))
+	scoreCmds := make([]*redis.FloatCmd, len(commentIDs))
+	countCmds := make([]*redis.FloatCmd, len(commentIDs))
+
+	for i, id := range commentIDs {
+		timeCmds[i] = pipeline.ZScore(getRedisKey(KeyCommentTimeZSetPF+postIDStr), id)
+		scoreCmds[i] = pipeline.ZScore(getRedisKey(KeyCommentScoreZSetPF+postIDStr), id)
+		countCmds[i] = pipeline.ZScore(getRedisKey(KeyCommentNumZSet), id)
+	}
+
+	if _, err := pipeline.Exec(); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	states := make([]CommentState, len(commentIDs))
+	for i := range commentIDs {
+		states[i] = CommentState{
+			InTime:   timeCmds[i].Err() == nil,
+			InScore:  scoreCmds[i].Err() == nil,
+			HasCount: countCmds[i].Err() == nil,
+			ReplyNum: countCmds[i].Val(),
+		}
+	}
+	return states, nil
+}
+
+// 按分数倒序查询 post:time 中的帖子id，count<=0 时返回全部
+func GetRecentPostIDs(offset, count int64) ([]string, error) {
+	end := int64(-1)
+	if count > 0 {
+		end = offset + count - 1
+	}
+	return client.ZRevRange(getRedisKey(KeyPostTimeZSet), offset, end).Result()
+}
+
+// 按分数倒序查询标签集合 tag:time:{tag} 或 tag:score:{tag}（key 不带项目前缀）中的帖子id，count<=0 时返回全部
+func GetTagPostIDs(key string, offset, count int64) ([]string, error) {
+	end := int64(-1)
+	if count > 0 {
+		end = offset + count - 1
+	}
+	return client.ZRevRange(getRedisKey(key), offset, end).Result()
+}
+
+// 从标签的时间和热度集合中移除帖子，并从这些帖子的标签集合中移除该标签
+func RemoveTagPosts(tag string, postIDs ...string) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = id
+	}
+
+	pipeline := client.TxPipeline()
+	pipeline.ZRem(getRedisKey(KeyTagTimeZSetPF+tag), members...)
+	pipeline.ZRem(getRedisKey(KeyTagScoreZSetPF+tag), members...)
+	for _, id := range postIDs {
+		pipeline.SRem(getRedisKey(KeyPostTagsSetPF+id), tag)
+	}
+	_, err := pipeline.Exec()
+	return err
+}
+
+// 向 zset 中写入成员（key 不带项目前缀），已存在的成员分数会被覆盖
+func ZAddMembers(key string, members map[string]float64) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]redis.Z, 0, len(members))
+	for member, score := range members {
+		zs = append(zs, redis.Z{Score: score, Member: member})
+	}
+	return client.ZAdd(getRedisKey(key), zs...).Err()
+}
+
+// CountRepair 计数集合中一个成员的修复：校验时读到的计数和按数据库计算的计数
+type CountRepair struct {
+	HasObserved bool    // 校验时成员是否存在
+	Observed    float64 // 校验时读到的计数
+	Expected    float64 // 按数据库计算的计数
+}
+
+// 成员的当前计数仍为校验时读到的值时才写入新计数，否则说明期间有评论新增或删除，不覆盖
+var repairCountScript = redis.NewScript(`
+local current = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if ARGV[2] == '' then
+	if current then
+		return 0
+	end
+elseif not current or tonumber(current) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1
+`)
+
+// 修复计数集合（key 不带项目前缀），返回实际修复的成员数
+// 计数在校验后被 ZIncrBy 修改过的成员跳过，留给下一轮校验，避免覆盖期间的增减
+func RepairCounts(key string, repairs map[string]CountRepair) (int, error) {
+	repaired := 0
+	for member, repair := range repairs {
+		observed := ""
+		if repair.HasObserved {
+			observed = strconv.FormatFloat(repair.Observed, 'f', -1, 64)
+		}
+		n, err := repairCountScript.Run(client, []string{getRedisKey(key)},
+			member, observed, strconv.FormatFloat(repair.Expected, 'f', -1, 64)).Int()
+		if err != nil {
+			return repaired, err
+		}
+		repaired += n
+	}
+	return repaired, nil
+}
+
+// 向 set 中添加成员（key 不带项目前缀）
+func SAddMembers(key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(members))
+	for i, m := range members {
+		values[i] = m
+	}
+	return client.SAdd(getRedisKey(key), values...).Err()
+}
+
+// 从全局帖子集合中移除已不存在的帖子（帖子的社区未知时使用）
+func RemoveOrphanPosts(postIDs ...string) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = id
+	}
+
+	// 查询帖子的标签，从标签的时间和热度集合中一并移除
+	tags, err := getPostTags(postIDs)
+	if err != nil {
+		return err
+	}
+
+	pipeline := client.TxPipeline()
+	pipeline.ZRem(getRedisKey(KeyPostTimeZSet), members...)
+	pipeline.ZRem(getRedisKey(KeyPostScoreZSet), members...)
+	pipeline.ZRem(getRedisKey(KeyPostCommentNumZSet), members...)
+	for _, id := range postIDs {
+		for _, tag := range tags[id] {
+			pipeline.ZRem(getRedisKey(KeyTagTimeZSetPF+tag), id)
+			pipeline.ZRem(getRedisKey(KeyTagScoreZSetPF+tag), id)
+		}
+		pipeline.Del(getRedisKey(KeyPostTagsSetPF + id))
+		pipeline.Del(getRedisKey(KeyPostVotedZSetPF + id))
+		pipeline.Del(getRedisKey(KeyCommentTimeZSetPF + id))
+		pipeline.Del(getRedisKey(KeyCommentScoreZSetPF + id))
+	}
+	_, err = pipeline.Exec()
+	return err
+}
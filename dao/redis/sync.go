@@ -0,0 +1,199 @@
+package redis
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis"
+)
+
+// 每次 pipeline 写入的成员数量
+const syncBatchSize = 1000
+
+// SyncResult 单个 key 的对比结果
+type SyncResult struct {
+	Missing    int `json:"missing"`    // 应该存在但 redis 中没有的成员
+	Mismatched int `json:"mismatched"` // 分数（或值）不一致的成员
+	Extra      int `json:"extra"`      // redis 中多出来的成员
+}
+
+// Changed 是否存在差异
+func (r *SyncResult) Changed() bool {
+	return r.Missing+r.Mismatched+r.Extra > 0
+}
+
+// SyncZSet 将 zset 与期望的成员和分数进行对比，dryRun 为 false 时修复差异
+// key 不带项目前缀，例如 KeyPostTimeZSet
+func SyncZSet(key string, expected map[string]float64, dryRun bool) (*SyncResult, error) {
+	return syncZSet(key, expected, false, dryRun)
+}
+
+// SyncZSetMembers 只对比 zset 的成员，已存在成员的分数保持不变（用于分数只保存在 redis 中的 key）
+// 缺失的成员以期望的分数补齐
+func SyncZSetMembers(key string, expected map[string]float64, dryRun bool) (*SyncResult, error) {
+	return syncZSet(key, expected, true, dryRun)
+}
+
+func syncZSet(key string, expected map[string]float64, keepScores, dryRun bool) (*SyncResult, error) {
+	fullKey := getRedisKey(key)
+	members, err := client.ZRangeWithScores(fullKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]float64, len(members))
+	for _, m := range members {
+		actual[m.Member.(string)] = m.Score
+	}
+
+	result := &SyncResult{}
+	var toAdd []redis.Z
+	for member, score := range expected {
+		old, ok := actual[member]
+		if !ok {
+			result.Missing++
+		} else if !keepScores && math.Abs(old-score) > 1e-6 {
+			result.Mismatched++
+		} else {
+			continue
+		}
+		toAdd = append(toAdd, redis.Z{Score: score, Member: member})
+	}
+
+	var toRemove []interface{}
+	for member := range actual {
+		if _, ok := expected[member]; !ok {
+			result.Extra++
+			toRemove = append(toRemove, member)
+		}
+	}
+
+	if dryRun || !result.Changed() {
+		return result, nil
+	}
+
+	for start := 0; start < len(toAdd); start += syncBatchSize {
+		end := min(start+syncBatchSize, len(toAdd))
+		if err := client.ZAdd(fullKey, toAdd[start:end]...).Err(); err != nil {
+			return result, err
+		}
+	}
+	for start := 0; start < len(toRemove); start += syncBatchSize {
+		end := min(start+syncBatchSize, len(toRemove))
+		if err := client.ZRem(fullKey, toRemove[start:end]...).Err(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// SyncSet 将 set 与期望的成员进行对比，dryRun 为 false 时修复差异
+func SyncSet(key string, expected map[string]struct{}, dryRun bool) (*SyncResult, error) {
+	fullKey := getRedisKey(key)
+	members, err := client.SMembers(fullKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		actual[m] = struct{}{}
+	}
+
+	result := &SyncResult{}
+	var toAdd, toRemove []interface{}
+	for member := range expected {
+		if _, ok := actual[member]; !ok {
+			result.Missing++
+			toAdd = append(toAdd, member)
+		}
+	}
+	for member := range actual {
+		if _, ok := expected[member]; !ok {
+			result.Extra++
+			toRemove = append(toRemove, member)
+		}
+	}
+
+	if dryRun || !result.Changed() {
+		return result, nil
+	}
+
+	pipeline := client.Pipeline()
+	if len(toAdd) > 0 {
+		pipeline.SAdd(fullKey, toAdd...)
+	}
+	if len(toRemove) > 0 {
+		pipeline.SRem(fullKey, toRemove...)
+	}
+	_, err = pipeline.Exec()
+	return result, err
+}
+
+// SyncHash 将整数值的 hash 与期望的字段和值进行对比，dryRun 为 false 时修复差异
+func SyncHash(key string, expected map[string]int64, dryRun bool) (*SyncResult, error) {
+	fullKey := getRedisKey(key)
+	fields, err := client.HGetAll(fullKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{}
+	toSet := make(map[string]interface{})
+	for field, value := range expected {
+		old, ok := fields[field]
+		if !ok {
+			result.Missing++
+		} else if old != strconv.FormatInt(value, 10) {
+			result.Mismatched++
+		} else {
+			continue
+		}
+		toSet[field] = value
+	}
+
+	var toRemove []string
+	for field := range fields {
+		if _, ok := expected[field]; !ok {
+			result.Extra++
+			toRemove = append(toRemove, field)
+		}
+	}
+
+	if dryRun || !result.Changed() {
+		return result, nil
+	}
+
+	pipeline := client.Pipeline()
+	if len(toSet) > 0 {
+		pipeline.HMSet(fullKey, toSet)
+	}
+	if len(toRemove) > 0 {
+		pipeline.HDel(fullKey, toRemove...)
+	}
+	_, err = pipeline.Exec()
+	return result, err
+}
+
+// ScanKeySuffixes 扫描指定前缀的所有 key，返回去掉前缀后的部分（例如 userID、标签名）
+// prefix 不带项目前缀，例如 KeyUserLikedPostsZSetPF
+func ScanKeySuffixes(prefix string) ([]string, error) {
+	fullPrefix := getRedisKey(prefix)
+
+	var suffixes []string
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(cursor, fullPrefix+"*", 500).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			suffixes = append(suffixes, strings.TrimPrefix(key, fullPrefix))
+		}
+		if next == 0 {
+			return suffixes, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"vision/constants"
+	"vision/models/request"
+)
+
+// 热门标签按小时分桶统计，最多统计最近 TrendingMaxHours 小时
+const TrendingMaxHours = 72
+
+// 单个标签的热度
+type TagTrend struct {
+	Name  string
+	Count int64 // 统计时间内的使用次数
+}
+
+// 标签使用次数的小时桶
+func tagTrendKey(t time.Time) string {
+	return getRedisKey(KeyTagTrendZSetPF + t.Format("2006010215"))
+}
+
+// 为新帖子添加标签：写入帖子的标签集合、各标签的时间和热度集合，并计入热门标签统计
+func AddPostTags(postID int64, tags []string, createdAt time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	postIDStr := strconv.FormatInt(postID, 10)
+
+	// 与 CreatePost 一致，初始热度为发布时间戳，后续由投票和排序算法更新
+	pipeline := client.TxPipeline()
+	addPostTags(pipeline, postIDStr, tags, float64(createdAt.Unix()), float64(createdAt.Unix()))
+	_, err := pipeline.Exec()
+	return err
+}
+
+// 编辑帖子后更新标签：从移除的标签集合中删除帖子，加入新增的标签集合
+func UpdatePostTags(postID int64, removed, added []string) error {
+	if len(removed) == 0 && len(added) == 0 {
+		return nil
+	}
+	postIDStr := strconv.FormatInt(postID, 10)
+
+	// 新增的标签沿用帖子当前的发布时间和热度
+	createdAt := client.ZScore(getRedisKey(KeyPostTimeZSet), postIDStr).Val()
+	score := client.ZScore(getRedisKey(KeyPostScoreZSet), postIDStr).Val()
+
+	pipeline := client.TxPipeline()
+	if len(removed) > 0 {
+		members := make([]interface{}, len(removed))
+		for i, tag := range removed {
+			members[i] = tag
+			pipeline.ZRem(getRedisKey(KeyTagTimeZSetPF+tag), postIDStr)
+			pipeline.ZRem(getRedisKey(KeyTagScoreZSetPF+tag), postIDStr)
+		}
+		pipeline.SRem(getRedisKey(KeyPostTagsSetPF+postIDStr), members...)
+	}
+	addPostTags(pipeline, postIDStr, added, createdAt, score)
+
+	_, err := pipeline.Exec()
+	return err
+}
+
+// 在 pipeline 中添加帖子标签
+func addPostTags(pipeline redis.Pipeliner, postIDStr string, tags []string, createdAt, score float64) {
+	if len(tags) == 0 {
+		return
+	}
+
+	members := make([]interface{}, len(tags))
+	trendKey := tagTrendKey(time.Now())
+	for i, tag := range tags {
+		members[i] = tag
+		pipeline.ZAdd(getRedisKey(KeyTagTimeZSetPF+tag), redis.Z{
+			Score:  createdAt,
+			Member: postIDStr,
+		})
+		pipeline.ZAdd(getRedisKey(KeyTagScoreZSetPF+tag), redis.Z{
+			Score:  score,
+			Member: postIDStr,
+		})
+		pipeline.ZIncrBy(trendKey, 1, tag)
+	}
+	pipeline.SAdd(getRedisKey(KeyPostTagsSetPF+postIDStr), members...)
+	pipeline.Expire(trendKey, (TrendingMaxHours+1)*time.Hour)
+}
+
+// 批量查询帖子的标签，返回 postID -> 标签名列表
+func getPostTags(postIDs []string) (map[string][]string, error) {
+	pipeline := client.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(postIDs))
+	for i, id := range postIDs {
+		cmds[i] = pipeline.SMembers(getRedisKey(KeyPostTagsSetPF + id))
+	}
+	if _, err := pipeline.Exec(); err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]string, len(postIDs))
+	for i, id := range postIDs {
+		if members := cmds[i].Val(); len(members) > 0 {
+			tags[id] = members
+		}
+	}
+	return tags, nil
+}
+
+// 根据排序方式分页查询标签下的帖子id列表
+func GetTagPostIDsInOrder(p *request.ListRequest, tag string) ([]string, int64, string, error) {
+	key := getRedisKey(KeyTagTimeZSetPF + tag)
+	if p.Order == constants.OrderScore {
+		key = getRedisKey(KeyTagScoreZSetPF + tag)
+	}
+	return getIDsFormKey(key, p)
+}
+
+// 查询最近 hours 小时内使用次数最多的标签
+func GetTrendingTags(hours int, limit int64) ([]TagTrend, error) {
+	if hours <= 0 || hours > TrendingMaxHours {
+		hours = TrendingMaxHours
+	}
+
+	now := time.Now()
+	keys := make([]string, hours)
+	for i := range keys {
+		keys[i] = tagTrendKey(now.Add(-time.Duration(i) * time.Hour))
+	}
+
+	// 合并最近若干小时的统计，结果缓存 60 秒
+	key := getRedisKey(KeyTagTrendingZSet) + ":" + strconv.Itoa(hours)
+	pipeline := client.Pipeline()
+	pipeline.ZUnionStore(key, redis.ZStore{Aggregate: "SUM"}, keys...)
+	pipeline.Expire(key, 60*time.Second)
+	if _, err := pipeline.Exec(); err != nil {
+		return nil, err
+	}
+
+	members, err := client.ZRevRangeWithScores(key, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	trends := make([]TagTrend, len(members))
+	for i, m := range members {
+		trends[i] = TagTrend{Name: m.Member.(string), Count: int64(m.Score)}
+	}
+	return trends, nil
+}
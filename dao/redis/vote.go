@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis"
@@ -25,6 +27,7 @@ direction=0时：
 	每个帖子自发表之日起一个星期之内允许用户投票，超过一个星期就不允许投票
     1、到期之后将redis中保存的赞成票及反对票存储到mysql表中
 	2、到期之后删除 KeyPostVotedZSetPF
+	以上由 service/archive 中的定时任务完成，归档后的票数保存在 KeyPostArchivedUpHash / KeyPostArchivedDownHash
 */
 
 // 为帖子投票
@@ -48,11 +51,17 @@ func VoteForPost(userID, postID string, direction float64) error {
 	ov := client.ZScore(getRedisKey(KeyPostVotedZSetPF+postID), userID).Val() // 上次投票类型：1 or 0 or -1
 	diff := direction - ov                                                    //计算两次投票类型的差值
 
+	//查询帖子的标签，标签热度集合与 post:score 同步更新
+	tags := client.SMembers(getRedisKey(KeyPostTagsSetPF + postID)).Val()
+
 	//开启事务
 	pipeline := client.TxPipeline()
 
 	//给指定的键和成员名增加分数
 	pipeline.ZIncrBy(getRedisKey(KeyPostScoreZSet), diff*constants.ScorePerVote*weight, postID)
+	for _, tag := range tags {
+		pipeline.ZIncrBy(getRedisKey(KeyTagScoreZSetPF+tag), diff*constants.ScorePerVote*weight, postID)
+	}
 
 	//更新用户为该帖子投票的数据
 	pipeline.ZAdd(getRedisKey(KeyPostVotedZSetPF+postID), redis.Z{
@@ -60,11 +69,14 @@ func VoteForPost(userID, postID string, direction float64) error {
 		Member: userID,
 	})
 
-	//更新用户的点赞记录
+	//更新用户的点赞记录，分数为点赞时间
 	if direction == 1 {
-		pipeline.SAdd(getRedisKey(KeyUserLikedPostsSetPF)+userID, postID)
+		pipeline.ZAdd(getRedisKey(KeyUserLikedPostsZSetPF+userID), redis.Z{
+			Score:  float64(time.Now().Unix()),
+			Member: postID,
+		})
 	} else {
-		pipeline.SRem(getRedisKey(KeyUserLikedPostsSetPF)+userID, postID)
+		pipeline.ZRem(getRedisKey(KeyUserLikedPostsZSetPF+userID), postID)
 	}
 
 	//执行事务
@@ -73,33 +85,33 @@ func VoteForPost(userID, postID string, direction float64) error {
 }
 
 // 为评论投票
-func VoteForComment(userID, commentID, postID string, direction float64, parentID *int64) error {
-	// 投票的权重，如果一级评论发布时间超过一周，则权重为0.5（减半）
+// 顶级评论的分数保存在 comment:score:{postID}，子评论的分数保存在根评论的 comment:reply_score:{rootID}
+// createdAt 为评论的发布时间，用于计算投票权重
+func VoteForComment(userID, commentID, postID string, direction float64, rootID *int64, createdAt time.Time) error {
+	// 投票的权重，如果评论发布时间超过一周，则权重为0.5（减半）
 	weight := 1.0
-
-	//判断投票限制
-	//利用redis获取帖子发布时间
-	//ZScore函数的两个参数：键名和成员名，获取该成员的分数score
-	//Val将结果转换为float64类型
-	commentTime := client.ZScore(getRedisKey(KeyCommentTimeZSetPF), commentID).Val()
-
-	//如果帖子发布时间超过一周，则权重减半
-	if float64(time.Now().Unix())-commentTime > constants.OneWeekInSeconds {
+	if float64(time.Now().Unix()-createdAt.Unix()) > constants.OneWeekInSeconds {
 		weight = 0.5
 	}
 
-	//更新一级评论分数
-	//查询当前用户(userID)给当前一级评论的投票记录
+	//查询当前用户(userID)给当前评论的投票记录
 	ov := client.ZScore(getRedisKey(KeyCommentVotedZSetPF+commentID), userID).Val() // 上次投票类型：1 or 0 or -1
 	diff := direction - ov                                                          //计算两次投票类型的差值
 
+	scoreKey := getRedisKey(KeyCommentScoreZSetPF + postID)
+	if rootID != nil {
+		scoreKey = getRedisKey(KeyCommentReplyScoreZSetPF + strconv.FormatInt(*rootID, 10))
+	}
+
 	//开启事务
 	pipeline := client.TxPipeline()
 
-	//给指定的键和成员名增加分数
-	if parentID == nil { // 如果是一级评论才更新
-		pipeline.ZIncrBy(getRedisKey(KeyCommentScoreZSetPF+postID), diff*constants.ScorePerVote*weight, commentID)
-	}
+	//更新评论分数，分数集合中缺少该评论时（例如早期的子评论）先以发布时间补齐
+	pipeline.ZAddNX(scoreKey, redis.Z{
+		Score:  float64(createdAt.Unix()),
+		Member: commentID,
+	})
+	pipeline.ZIncrBy(scoreKey, diff*constants.ScorePerVote*weight, commentID)
 
 	//更新用户为该评论投票的数据
 	pipeline.ZAdd(getRedisKey(KeyCommentVotedZSetPF+commentID), redis.Z{
@@ -107,17 +119,74 @@ func VoteForComment(userID, commentID, postID string, direction float64, parentI
 		Member: userID,
 	})
 
-	//更新用户点过赞的评论集合
-	pipeline.SAdd(getRedisKey(KeyUserLikedCommentsSetPF)+userID, commentID)
+	//用户点过赞的评论集合只保存当前投赞成票的评论
+	if direction == 1 {
+		pipeline.SAdd(getRedisKey(KeyUserLikedCommentsSetPF)+userID, commentID)
+	} else {
+		pipeline.SRem(getRedisKey(KeyUserLikedCommentsSetPF)+userID, commentID)
+	}
 
 	//执行事务
 	_, err := pipeline.Exec()
 	return err
 }
 
+// 查询帖子的全部投票记录，返回 userID -> 投票类型（1 / -1 / 0）
+func GetPostVoteRecords(postID string) (map[string]float64, error) {
+	members, err := client.ZRangeWithScores(getRedisKey(KeyPostVotedZSetPF+postID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]float64, len(members))
+	for _, m := range members {
+		records[m.Member.(string)] = m.Score
+	}
+	return records, nil
+}
+
+// 查询帖子当前的热度分数，帖子不在 post:score 中时返回0
+func GetPostScore(postID string) (float64, error) {
+	score, err := client.ZScore(getRedisKey(KeyPostScoreZSet), postID).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return score, err
+}
+
+// 归档帖子投票：保存最终的赞成/反对票数，并删除 post:voted:{postID}
+// 调用前投票记录应已写入数据库
+func ArchivePostVotes(postID string, ups, downs int64) error {
+	pipeline := client.TxPipeline()
+
+	pipeline.HSet(getRedisKey(KeyPostArchivedUpHash), postID, ups)
+	pipeline.HSet(getRedisKey(KeyPostArchivedDownHash), postID, downs)
+	pipeline.Del(getRedisKey(KeyPostVotedZSetPF + postID))
+
+	_, err := pipeline.Exec()
+	return err
+}
+
 // 查询用户是否点赞过该帖子
 func IsUserLikedPost(userID string, postID string) (bool, error) {
-	return client.SIsMember(getRedisKey(KeyUserLikedPostsSetPF+userID), postID).Result()
+	err := client.ZScore(getRedisKey(KeyUserLikedPostsZSetPF+userID), postID).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// 从点赞过该帖子的用户的点赞记录中移除帖子（删除帖子时使用）
+func RemoveLikedPost(postID string, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	pipeline := client.Pipeline()
+	for _, userID := range userIDs {
+		pipeline.ZRem(getRedisKey(KeyUserLikedPostsZSetPF+strconv.FormatInt(userID, 10)), postID)
+	}
+	_, err := pipeline.Exec()
+	return err
 }
 
 // 查询用户是否点赞过该评论
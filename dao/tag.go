@@ -0,0 +1,122 @@
+package dao
+
+import (
+	"vision/dao/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vision/models/entity"
+)
+
+// 保存帖子的标签，不存在的标签会自动创建
+func SavePostTags(postID int64, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return postgres.DB.Transaction(func(tx *gorm.DB) error {
+		return addPostTags(tx, postID, names)
+	})
+}
+
+// 用新的标签列表替换帖子原有的标签，返回被移除和新增的标签
+func ReplacePostTags(postID int64, names []string) (removed, added []string, err error) {
+	err = postgres.DB.Transaction(func(tx *gorm.DB) error {
+		var current []*entity.Tag
+		if err := tx.Model(&entity.Tag{}).
+			Joins("JOIN post_tag ON post_tag.tag_id = tag.id").
+			Where("post_tag.post_id = ?", postID).
+			Find(&current).Error; err != nil {
+			return err
+		}
+
+		keep := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			keep[name] = struct{}{}
+		}
+		existing := make(map[string]struct{}, len(current))
+		var removedIDs []int64
+		for _, tag := range current {
+			existing[tag.Name] = struct{}{}
+			if _, ok := keep[tag.Name]; !ok {
+				removed = append(removed, tag.Name)
+				removedIDs = append(removedIDs, tag.ID)
+			}
+		}
+		for _, name := range names {
+			if _, ok := existing[name]; !ok {
+				added = append(added, name)
+			}
+		}
+
+		if len(removedIDs) > 0 {
+			if err := tx.Where("post_id = ? AND tag_id IN ?", postID, removedIDs).Delete(&entity.PostTag{}).Error; err != nil {
+				return err
+			}
+		}
+		return addPostTags(tx, postID, added)
+	})
+	return
+}
+
+// 在事务中创建标签并关联到帖子
+func addPostTags(tx *gorm.DB, postID int64, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	// 创建不存在的标签
+	tags := make([]*entity.Tag, len(names))
+	for i, name := range names {
+		tags[i] = &entity.Tag{Name: name}
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&tags).Error; err != nil {
+		return err
+	}
+
+	// 冲突时 Create 不会回填 ID，重新按名称查询
+	var tagIDs []int64
+	if err := tx.Model(&entity.Tag{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+		return err
+	}
+
+	postTags := make([]*entity.PostTag, len(tagIDs))
+	for i, tagID := range tagIDs {
+		postTags[i] = &entity.PostTag{PostID: postID, TagID: tagID}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&postTags).Error
+}
+
+// 删除帖子的标签关联
+func DeletePostTags(postID int64) error {
+	return postgres.DB.Where("post_id = ?", postID).Delete(&entity.PostTag{}).Error
+}
+
+// 批量查询帖子的标签，返回 postID -> 标签名列表
+func GetPostTagNames(postIDs []int64) (map[int64][]string, error) {
+	tagNames := make(map[int64][]string, len(postIDs))
+	if len(postIDs) == 0 {
+		return tagNames, nil
+	}
+
+	var rows []struct {
+		PostID int64
+		Name   string
+	}
+	err := postgres.DB.Table("post_tag").
+		Select("post_tag.post_id, tag.name").
+		Joins("JOIN tag ON tag.id = post_tag.tag_id").
+		Where("post_tag.post_id IN ?", postIDs).
+		Order("post_tag.created_at ASC, tag.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tagNames[row.PostID] = append(tagNames[row.PostID], row.Name)
+	}
+	return tagNames, nil
+}
@@ -108,6 +108,36 @@ func GetUserBriefInfo(id int64) (*response.UserBriefResponse, error) {
 	return userBriefResponse, err
 }
 
+// 批量查询用户简略信息
+func GetUserBriefInfos(ids []int64) ([]*response.UserBriefResponse, error) {
+	var users []*response.UserBriefResponse
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := postgres.DB.Model(&entity.User{}).Select("id", "username", "avatar").Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// 根据用户名批量查询用户id，返回 username -> userID（不存在的用户名不在结果中）
+func GetUserIDsByUsernames(usernames []string) (map[string]int64, error) {
+	ids := make(map[string]int64, len(usernames))
+	if len(usernames) == 0 {
+		return ids, nil
+	}
+
+	var users []*entity.User
+	err := postgres.DB.Select("id", "username").Where("username IN ?", usernames).Order("id").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if _, ok := ids[user.Username]; !ok { // 用户名重复时取最早注册的用户
+			ids[user.Username] = user.ID
+		}
+	}
+	return ids, nil
+}
+
 // 根据邮箱更新用户密码
 func UpdatePassword(user *entity.User) error {
 	// 忽略零值动态更新
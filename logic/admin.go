@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"context"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/service/kafka"
+	"vision/service/rebuild"
+	"vision/service/reconcile"
+)
+
+// 查看死信消息时的默认条数
+const defaultDeadLetterSize = 20
+
+// 根据数据库重建 redis 中的排序和计数数据
+func RebuildRedis(dryRun bool) (*rebuild.Report, error) {
+	return rebuild.Run(dryRun)
+}
+
+// 获取最近一次 redis 一致性校验报告
+func GetRedisDriftReport() (*reconcile.DriftReport, error) {
+	report := reconcile.LastReport()
+	if report == nil {
+		return nil, constants.ErrorNoResult
+	}
+	return report, nil
+}
+
+// 立即执行一次 redis 一致性校验
+func ReconcileRedis(mode string, dryRun bool) (*reconcile.DriftReport, error) {
+	return reconcile.Run(mode, dryRun)
+}
+
+// 查看最近的死信消息
+func ListDeadLetters(ctx context.Context, size int) ([]*kafka.DeadLetter, error) {
+	if size <= 0 {
+		size = defaultDeadLetterSize
+	}
+	return kafka.ListDeadLetters(ctx, size)
+}
+
+// 把死信消息重新投递到原主题
+func ReplayDeadLetter(ctx context.Context, partition int, offset int64) (*kafka.DeadLetter, error) {
+	return kafka.ReplayDeadLetter(ctx, partition, offset)
+}
+
+// 查询 kafka 发件箱转发指标
+func GetOutboxStats() (*kafka.OutboxStats, error) {
+	return kafka.GetOutboxStats()
+}
+
+// 任命社区版主
+func AddCommunityModerator(communityID, userID int64) error {
+	if _, err := dao.GetCommunityById(communityID); err != nil {
+		return err
+	}
+	if _, err := dao.GetUserByID(userID); err != nil {
+		return err
+	}
+	return dao.AddCommunityModerator(communityID, userID)
+}
+
+// 撤销社区版主
+func RemoveCommunityModerator(communityID, userID int64) error {
+	return dao.RemoveCommunityModerator(communityID, userID)
+}
@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // 注册 jpeg 解码器，用于识别图片尺寸
+	_ "image/png"  // 注册 png 解码器，用于识别图片尺寸
+	"io"
+	"mime/multipart"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/models/entity"
+	"vision/models/request"
+	"vision/models/response"
+	"vision/pkg/alioss"
+	"vision/settings"
+)
+
+// 未被帖子使用的附件保留时长，过期后由定时任务清理
+const PendingAttachmentTTL = 24 * time.Hour
+
+// 上传帖子图片，返回图片地址（用于帖子的 image 字段）
+// 同时保存为待使用的附件，发帖或编辑时 image 字段引用该地址则绑定到帖子，否则过期后自动清理
+func UploadPostImage(uploaderID int64, file multipart.File, ext string, size int64) (string, error) {
+	fileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
+	path := settings.Conf.AliossConfig.PostImagePtah
+	fileURL, err := alioss.UploadFile(file, fileName, path)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(PendingAttachmentTTL)
+	attachment := &entity.PostAttachment{
+		UploaderID: uploaderID,
+		URL:        fileURL,
+		ObjectName: path + fileName,
+		MediaType:  entity.MediaTypeImage,
+		Size:       size,
+		ExpiresAt:  &expiresAt,
+	}
+	if err := dao.CreateAttachment(attachment); err != nil {
+		return "", err
+	}
+	return fileURL, nil
+}
+
+// 上传帖子附件，保存为待使用状态
+func UploadPostAttachment(uploaderID int64, file multipart.File, ext, mediaType string, size int64, p *request.UploadAttachmentRequest) (*response.AttachmentResponse, error) {
+	width, height := p.Width, p.Height
+
+	// 图片自动识别尺寸，识别后需要把读取位置重置到文件开头
+	if mediaType == entity.MediaTypeImage {
+		if config, _, err := image.DecodeConfig(file); err == nil {
+			width, height = config.Width, config.Height
+		} else {
+			zap.L().Warn("识别图片尺寸失败", zap.Error(err))
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	// 生成唯一文件名并上传到 OSS
+	fileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
+	path := settings.Conf.AliossConfig.PostImagePtah
+	fileURL, err := alioss.UploadFile(file, fileName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(PendingAttachmentTTL)
+	attachment := &entity.PostAttachment{
+		UploaderID: uploaderID,
+		URL:        fileURL,
+		ObjectName: path + fileName,
+		MediaType:  mediaType,
+		Width:      width,
+		Height:     height,
+		Size:       size,
+		ExpiresAt:  &expiresAt,
+	}
+	if err := dao.CreateAttachment(attachment); err != nil {
+		return nil, err
+	}
+
+	return toAttachmentResponse(attachment), nil
+}
+
+// 批量查询帖子的附件，查询失败时返回空 map
+func getPostAttachments(postIDs []int64) map[int64][]*response.AttachmentResponse {
+	attachmentResponses := make(map[int64][]*response.AttachmentResponse, len(postIDs))
+
+	attachments, err := dao.GetPostAttachments(postIDs)
+	if err != nil { // 遇到错误不返回，按无附件处理
+		zap.L().Error("查询帖子附件失败", zap.Error(err))
+		return attachmentResponses
+	}
+	for postID, list := range attachments {
+		for _, attachment := range list {
+			attachmentResponses[postID] = append(attachmentResponses[postID], toAttachmentResponse(attachment))
+		}
+	}
+	return attachmentResponses
+}
+
+func toAttachmentResponse(attachment *entity.PostAttachment) *response.AttachmentResponse {
+	return &response.AttachmentResponse{
+		ID:        attachment.ID,
+		URL:       attachment.URL,
+		MediaType: attachment.MediaType,
+		Width:     attachment.Width,
+		Height:    attachment.Height,
+	}
+}
@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/models/entity"
+	"vision/models/request"
+	"vision/models/response"
+)
+
+// 收藏帖子
+func BookmarkPost(postID, userID int64, p *request.BookmarkPostRequest) error {
+	// 校验帖子是否存在
+	if _, err := dao.GetPublishedPostById(postID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return constants.ErrorNoPost
+		}
+		return err
+	}
+
+	// 校验收藏夹是否属于当前用户，0 视为未分类
+	folderID := p.FolderID
+	if folderID != nil && *folderID == 0 {
+		folderID = nil
+	}
+	if folderID != nil {
+		if _, err := dao.GetBookmarkFolder(*folderID, userID); err != nil {
+			return err
+		}
+	}
+
+	return dao.BookmarkPost(userID, postID, folderID)
+}
+
+// 取消收藏帖子
+func UnbookmarkPost(postID, userID int64) error {
+	return dao.UnbookmarkPost(userID, postID)
+}
+
+// 查询用户收藏的帖子列表
+func GetUserBookmarkedPostList(userID int64, p *request.BookmarkListRequest) (postListResponse *response.PostListResponse, err error) {
+	postListResponse = &response.PostListResponse{
+		Posts: []*response.PostResponse{},
+	}
+
+	// 查询指定收藏夹时，校验收藏夹是否属于当前用户
+	if p.FolderID != nil && *p.FolderID != 0 {
+		if _, err = dao.GetBookmarkFolder(*p.FolderID, userID); err != nil {
+			return
+		}
+	}
+
+	ids, total, err := dao.GetUserBookmarkedPostIDs(&p.ListRequest, userID, p.FolderID)
+	if err != nil {
+		return
+	}
+	postListResponse.Total = total
+	if len(ids) == 0 {
+		return
+	}
+
+	// 根据id列表查询帖子详情
+	postListResponse.Posts, err = GetPostListByIDs(ids, userID)
+	return
+}
+
+// 创建收藏夹
+func CreateBookmarkFolder(userID int64, p *request.CreateBookmarkFolderRequest) (*response.BookmarkFolderResponse, error) {
+	folder := &entity.BookmarkFolder{
+		UserID: userID,
+		Name:   p.Name,
+	}
+	if err := dao.CreateBookmarkFolder(folder); err != nil {
+		return nil, err
+	}
+
+	return &response.BookmarkFolderResponse{
+		ID:        folder.ID,
+		Name:      folder.Name,
+		CreatedAt: folder.CreatedAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// 查询用户的收藏夹列表
+func GetBookmarkFolderList(userID int64) (*response.BookmarkFolderListResponse, error) {
+	folders, err := dao.GetBookmarkFolders(userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := dao.CountUserBookmarksByFolder(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	folderListResponse := &response.BookmarkFolderListResponse{
+		Folders:            make([]*response.BookmarkFolderResponse, 0, len(folders)),
+		UncategorizedCount: counts[0],
+	}
+	for _, folder := range folders {
+		folderListResponse.Folders = append(folderListResponse.Folders, &response.BookmarkFolderResponse{
+			ID:            folder.ID,
+			Name:          folder.Name,
+			BookmarkCount: counts[folder.ID],
+			CreatedAt:     folder.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return folderListResponse, nil
+}
+
+// 删除收藏夹，其中的收藏移回未分类
+func DeleteBookmarkFolder(folderID, userID int64) error {
+	return dao.DeleteBookmarkFolder(folderID, userID)
+}
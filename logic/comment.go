@@ -3,6 +3,7 @@ package logic
 import (
 	"errors"
 	"strconv"
+	"time"
 	"vision/dao"
 
 	"go.uber.org/zap"
@@ -13,12 +14,13 @@ import (
 	"vision/models/entity"
 	"vision/models/request"
 	"vision/models/response"
+	"vision/service/mention"
 )
 
 // 创建评论
 func CreateComment(createCommentRequest *request.CreateCommentRequest, userID int64) (*response.CommentResponse, error) {
 	// 在mysql中查询postID是否存在
-	_, err := dao.GetPostById(createCommentRequest.PostID)
+	_, err := dao.GetPublishedPostById(createCommentRequest.PostID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
 			return nil, constants.ErrorNoPost
@@ -44,12 +46,22 @@ func CreateComment(createCommentRequest *request.CreateCommentRequest, userID in
 		err = redis.CreateTopComment(comment.ID, comment.PostID)
 	} else {
 		// 创建子评论
-		err = redis.CreateSonComment(*createCommentRequest.RootID, createCommentRequest.PostID)
+		err = redis.CreateSonComment(comment.ID, *createCommentRequest.RootID, createCommentRequest.PostID)
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	// 解析并保存 @提及，通知被提及的用户
+	mentions, err := mention.Save(comment.PostID, comment.ID, userID, comment.Content)
+	if err != nil { // 评论已经保存，遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("保存提及失败", zap.Int64("comment_id", comment.ID), zap.Error(err))
+	}
+	if err := mention.Notify(mentions); err != nil { // 遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("发送提及通知失败", zap.Error(err))
+	}
+	mentionResponses := toMentionResponses(mentions)
+
 	// 查询作者信息
 	author, err := dao.GetUserBriefInfo(userID)
 	if err != nil {
@@ -69,6 +81,7 @@ func CreateComment(createCommentRequest *request.CreateCommentRequest, userID in
 			Author:    author,
 			Parent:    parentUserinfo,
 			CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
+			Mentions:  mentionResponses,
 			RootID:    *comment.RootID,
 			ParentID:  *comment.ParentID,
 		}
@@ -82,6 +95,7 @@ func CreateComment(createCommentRequest *request.CreateCommentRequest, userID in
 			Content:   comment.Content,
 			Author:    author,
 			CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
+			Mentions:  mentionResponses,
 			RootID:    *comment.RootID,
 		}
 		return commentResponse, nil
@@ -95,38 +109,213 @@ func CreateComment(createCommentRequest *request.CreateCommentRequest, userID in
 		Author:       author,
 		RepliesCount: &repliesCount,
 		CreatedAt:    comment.CreatedAt.Format("2006-01-02 15:04:05"),
+		Mentions:     mentionResponses,
 	}
 	return commentResponse, nil
 }
 
-// 删除评论
+// 删除评论：有回复的评论保留为墓碑，回复不受影响
 func DeleteComment(commentID int64, userID int64) error {
 	// 先从mysql中查找评论
-	ids := []string{strconv.Itoa(int(commentID))}
-	comment, err := dao.GetCommentListByIDs(ids)
+	comment, err := dao.GetCommentByID(commentID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果未找到此评论
+			return constants.ErrorNoComment
+		}
 		return err
 	}
-	if len(comment) == 0 { // 如果未找到此评论
+	if comment.TombstonedAt != nil { // 已经删除，只作为墓碑保留
 		return constants.ErrorNoComment
 	}
 
 	// 校验userID
-	if comment[0].AuthorID != userID {
+	if comment.AuthorID != userID {
 		return constants.ErrorNoPermission
 	}
 
 	// 在mysql中删除评论
-	if err := dao.DeleteComment(commentID); err != nil {
+	tombstoned, removed, err := dao.DeleteComment(comment)
+	if err != nil {
+		return err
+	}
+	if err := dao.ReplaceMentions(comment.PostID, comment.ID, nil); err != nil { // 删除后内容已清空
 		return err
 	}
 
 	// 在redis中删除评论
-	if err := redis.DeleteComment(commentID, comment[0].PostID, comment[0].RootID); err != nil {
+	if err := redis.DeleteComment(commentID, comment.PostID, comment.RootID, tombstoned); err != nil {
 		return err
 	}
+	refs := make([]redis.CommentRef, len(removed))
+	for i, parent := range removed {
+		refs[i] = redis.CommentRef{ID: parent.ID, RootID: parent.RootID}
+	}
+	return redis.RemoveCommentTombstones(comment.PostID, refs)
+}
+
+// 已删除评论（墓碑）展示的内容
+const deletedCommentContent = "[deleted]"
+
+// 墓碑评论隐藏内容和作者，只保留在评论树中的位置
+func hideTombstone(commentResponse *response.CommentResponse, comment *entity.Comment) {
+	if comment.TombstonedAt == nil {
+		return
+	}
+	commentResponse.Content = deletedCommentContent
+	commentResponse.Author = nil
+	commentResponse.EditedAt = ""
+	commentResponse.Deleted = true
+	commentResponse.Mentions = nil
+}
+
+// 编辑评论：作者可以编辑自己的评论，管理员和该社区版主可以编辑他人评论（记录管理操作）
+func UpdateComment(commentID, userID int64, p *request.UpdateCommentRequest) (*response.CommentResponse, error) {
+	comment, err := dao.GetCommentByID(commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到评论
+			return nil, constants.ErrorNoComment
+		}
+		return nil, err
+	}
+	if comment.TombstonedAt != nil { // 已删除的评论不能编辑
+		return nil, constants.ErrorNoComment
+	}
+
+	// 非作者编辑时校验管理权限，并记录管理操作
+	var log *entity.ModerationLog
+	if comment.AuthorID != userID {
+		post, err := dao.GetPostById(comment.PostID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, constants.ErrorNoComment
+			}
+			return nil, err
+		}
+		ok, err := canModerateCommunity(post.CommunityID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, constants.ErrorNoPermission
+		}
+		log = &entity.ModerationLog{
+			ModeratorID:  userID,
+			CommunityID:  post.CommunityID,
+			Action:       entity.ModerationActionEditComment,
+			TargetType:   "comment",
+			TargetID:     comment.ID,
+			TargetUserID: comment.AuthorID,
+			Reason:       p.Reason,
+			Detail:       comment.Content,
+		}
+	}
 
-	return nil
+	// 保存历史版本并更新评论
+	if err := dao.UpdateComment(comment, p.Content, userID, log); err != nil {
+		return nil, err
+	}
+
+	// 按新内容重新保存 @提及，只通知新增的被提及用户（管理员修改时提及仍归属原作者）
+	mentions, err := mention.Save(comment.PostID, comment.ID, comment.AuthorID, p.Content)
+	if err != nil { // 评论已经更新，遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("保存提及失败", zap.Int64("comment_id", comment.ID), zap.Error(err))
+	}
+	if err := mention.Notify(mentions); err != nil { // 遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("发送提及通知失败", zap.Error(err))
+	}
+	if log != nil {
+		zap.L().Info("管理员编辑了他人评论",
+			zap.Int64("moderator_id", userID),
+			zap.Int64("comment_id", comment.ID),
+			zap.Int64("author_id", comment.AuthorID))
+	}
+
+	return getCommentResponse(comment, userID)
+}
+
+// 判断用户是否可以管理该社区的内容：管理员或该社区版主
+func canModerateCommunity(communityID, userID int64) (bool, error) {
+	user, err := dao.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if user.Role == constants.RoleAdmin {
+		return true, nil
+	}
+	return dao.IsCommunityModerator(communityID, userID)
+}
+
+// 封装单条评论的响应数据，字段与评论列表保持一致
+func getCommentResponse(comment *entity.Comment, userID int64) (*response.CommentResponse, error) {
+	ids := []string{strconv.FormatInt(comment.ID, 10)}
+	l := newLoader(userID)
+	voteData, err := l.commentVotes(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	commentResponse := &response.CommentResponse{
+		ID:            comment.ID,
+		Content:       comment.Content,
+		LikeCount:     voteData[0].Ups,
+		DislikeCount:  voteData[0].Downs,
+		Score:         voteData[0].Score(),
+		VoteDirection: voteData[0].Direction,
+		CreatedAt:     comment.CreatedAt.Format("2006-01-02 15:04:05"),
+		EditedAt:      formatEditedAt(comment.EditedAt),
+		Mentions:      getCommentMentions([]int64{comment.ID})[comment.ID],
+	}
+
+	authorIDs := []int64{comment.AuthorID}
+	switch {
+	case comment.RootID == nil: // 顶级评论（展示回复数）
+		commentNum, err := redis.GetSonCommentNumByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		repliesCount := int64(commentNum[0])
+		commentResponse.RepliesCount = &repliesCount
+	case *comment.ParentID != *comment.RootID: // 二级以上评论（展示父评论作者信息）
+		parentAuthorIDs, err := dao.GetCommentAuthorIDs([]int64{*comment.ParentID})
+		if err != nil {
+			return nil, err
+		}
+		if parentAuthorID, ok := parentAuthorIDs[*comment.ParentID]; ok {
+			authorIDs = append(authorIDs, parentAuthorID)
+			commentResponse.Parent = &response.UserBriefResponse{ID: parentAuthorID}
+		}
+		commentResponse.RootID = *comment.RootID
+		commentResponse.ParentID = *comment.ParentID
+	default: // 二级评论
+		commentResponse.RootID = *comment.RootID
+	}
+
+	// 批量加载评论作者和父评论作者信息
+	l.loadUsers(authorIDs)
+	author := l.user(comment.AuthorID)
+	commentResponse.Author = &author
+	if commentResponse.Parent != nil {
+		parentAuthor := l.user(commentResponse.Parent.ID)
+		commentResponse.Parent = &parentAuthor
+	}
+	return commentResponse, nil
+}
+
+// 提取评论id列表
+func commentIDsOf(comments []*entity.Comment) []int64 {
+	ids := make([]int64, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	return ids
+}
+
+// 查询投票方向时使用的用户id，游客（userID 为0）不查询
+func commentVoter(userID int64) string {
+	if userID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(userID, 10)
 }
 
 // 查询单个帖子的顶级评论
@@ -136,11 +325,12 @@ func GetTopCommentList(postID int64, listRequest *request.ListRequest, userID in
 	}
 
 	//从redis中，根据指定的排序方式和查询数量，查询符合条件的顶级评论id列表
-	ids, total, err := redis.GetTopCommentIDsInOrder(listRequest, postID)
+	ids, total, next, err := redis.GetTopCommentIDsInOrder(listRequest, postID)
 	if err != nil {
 		return
 	}
 	commentListResponse.Total = total
+	commentListResponse.NextCursor = next
 	if len(ids) == 0 {
 		return
 	}
@@ -151,60 +341,104 @@ func GetTopCommentList(postID int64, listRequest *request.ListRequest, userID in
 		return
 	}
 
-	// 查询所有顶级评论的赞成票数——切片
-	voteData, err := redis.GetCommentVoteDataByIDs(ids)
+	// 查询所有顶级评论的投票数据（包含当前用户的投票方向）——切片
+	l := newLoader(userID)
+	voteData, err := l.commentVotes(ids)
 	if err != nil {
 		return
 	}
 
 	// 查询所有顶级评论的子评论数——切片
 	commentNum, err := redis.GetSonCommentNumByIDs(ids)
+	if err != nil {
+		return
+	}
 
-	//将帖子作者及分区信息查询出来填充到帖子中
-	for idx, comment := range comments {
-		//查询作者简略信息
-		userBriefInfo, err := dao.GetUserBriefInfo(comment.AuthorID)
-		if err != nil { // 遇到错误不返回，继续执行后续逻辑
-			zap.L().Error("查询作者信息失败", zap.Error(err))
-			continue
-		}
+	// 批量加载评论作者信息
+	authorIDs := make([]int64, len(comments))
+	for i, comment := range comments {
+		authorIDs[i] = comment.AuthorID
+	}
+	l.loadUsers(authorIDs)
 
-		//查询当前用户是否点赞了此评论
-		liked, err := redis.IsUserLikedComment(strconv.Itoa(int(userID)), strconv.Itoa(int(comment.ID)))
-		if err != nil { // 遇到错误不返回，继续执行后续逻辑
-			zap.L().Error("查询用户是否点赞失败", zap.Error(err))
-			continue
-		}
+	// 批量查询评论中的 @提及
+	mentions := getCommentMentions(commentIDsOf(comments))
 
+	//将评论作者信息填充到评论中
+	for idx, comment := range comments {
 		//封装查询到的信息
+		author := l.user(comment.AuthorID)
 		repliesCount := int64(commentNum[idx])
 		commentResponse := &response.CommentResponse{
-			ID:           comment.ID,
-			Content:      comment.Content,
-			Author:       userBriefInfo,
-			LikeCount:    voteData[idx],
-			Liked:        liked,
-			RepliesCount: &repliesCount,
-			CreatedAt:    comment.CreatedAt.Format("2006-01-02 15:04:05"),
+			ID:            comment.ID,
+			Content:       comment.Content,
+			Author:        &author,
+			LikeCount:     voteData[idx].Ups,
+			DislikeCount:  voteData[idx].Downs,
+			Score:         voteData[idx].Score(),
+			VoteDirection: voteData[idx].Direction,
+			RepliesCount:  &repliesCount,
+			CreatedAt:     comment.CreatedAt.Format("2006-01-02 15:04:05"),
+			EditedAt:      formatEditedAt(comment.EditedAt),
 		}
 
+		commentResponse.Mentions = mentions[comment.ID]
+		hideTombstone(commentResponse, comment)
 		commentListResponse.Comments = append(commentListResponse.Comments, commentResponse)
 	}
 	return
 }
 
+// 子评论分数集合只包含按分数排序上线后发布或被投过票的子评论，
+// 集合中的评论数少于数据库中的子评论数时，以发布时间为默认分数补齐缺少的子评论
+func backfillSonCommentScores(rootID int64) error {
+	count, err := redis.CountSonCommentScores(rootID)
+	if err != nil {
+		return err
+	}
+	total, err := dao.CountSonComments(rootID)
+	if err != nil || count >= total {
+		return err
+	}
+
+	comments, err := dao.GetSonCommentTimes(rootID)
+	if err != nil {
+		return err
+	}
+	createdAt := make(map[int64]time.Time, len(comments))
+	for _, comment := range comments {
+		createdAt[comment.ID] = comment.CreatedAt
+	}
+	return redis.AddSonCommentScores(rootID, createdAt)
+}
+
 // 查询单个顶级评论的子评论
 func GetSonCommentList(rootID int64, listRequest *request.ListRequest, userID int64) (commentListResponse *response.CommentListResponse, err error) {
 	commentListResponse = &response.CommentListResponse{
 		Comments: []*response.CommentResponse{},
 	}
 
-	// 从mysql中查询子评论
-	comments, total, err := dao.GetSonCommentList(rootID, listRequest.Page, listRequest.Size)
-	if err != nil {
-		return
+	// 按投票分数排序时从redis中查询子评论id列表，否则从mysql中按时间正序分页查询子评论
+	var comments []*entity.Comment
+	if listRequest.Order == constants.OrderScore {
+		if err = backfillSonCommentScores(rootID); err != nil {
+			return
+		}
+		var ids []string
+		ids, commentListResponse.Total, commentListResponse.NextCursor, err = redis.GetSonCommentIDsByScore(listRequest, rootID)
+		if err != nil || len(ids) == 0 {
+			return
+		}
+		if comments, err = dao.GetCommentListByIDs(ids); err != nil {
+			return
+		}
+	} else {
+		var total int64
+		if comments, total, err = dao.GetSonCommentList(rootID, listRequest.Page, listRequest.Size); err != nil {
+			return
+		}
+		commentListResponse.Total = total
 	}
-	commentListResponse.Total = total
 	if len(comments) == 0 {
 		return
 	}
@@ -215,64 +449,88 @@ func GetSonCommentList(rootID int64, listRequest *request.ListRequest, userID in
 		commentIDs = append(commentIDs, strconv.FormatInt(comment.ID, 10))
 	}
 
-	// 查询所有子评论的赞成票数——切片
-	voteData, err := redis.GetCommentVoteDataByIDs(commentIDs)
+	// 查询所有子评论的投票数据（包含当前用户的投票方向）——切片
+	l := newLoader(userID)
+	voteData, err := l.commentVotes(commentIDs)
 	if err != nil {
 		return
 	}
 
-	// 将帖子作者及分区信息查询出来填充到帖子中
-	for idx, comment := range comments {
-		//查询作者简略信息
-		userBriefInfo, err := dao.GetUserBriefInfo(comment.AuthorID)
-		if err != nil {
-			zap.L().Error("查询作者信息失败", zap.Error(err))
-			continue
+	// 二级以上评论需要展示父评论的作者，批量查询父评论的作者id
+	var parentIDs []int64
+	for _, comment := range comments {
+		if *comment.ParentID != *comment.RootID {
+			parentIDs = append(parentIDs, *comment.ParentID)
 		}
+	}
+	parentAuthorIDs, err := dao.GetCommentAuthorIDs(parentIDs)
+	if err != nil {
+		return
+	}
 
-		//查询当前用户是否点赞了此评论
-		liked, err := redis.IsUserLikedComment(strconv.Itoa(int(userID)), strconv.Itoa(int(comment.ID)))
-		if err != nil {
-			zap.L().Error("查询用户是否点赞失败", zap.Error(err))
-			continue
-		}
+	// 批量加载评论作者和父评论作者信息
+	authorIDs := make([]int64, 0, len(comments)+len(parentAuthorIDs))
+	for _, comment := range comments {
+		authorIDs = append(authorIDs, comment.AuthorID)
+	}
+	for _, authorID := range parentAuthorIDs {
+		authorIDs = append(authorIDs, authorID)
+	}
+	l.loadUsers(authorIDs)
+
+	// 批量查询评论中的 @提及
+	mentions := getCommentMentions(commentIDsOf(comments))
 
-		//如果是二级以上评论，则需要查询父评论的作者信息
+	// 将评论作者信息填充到评论中
+	for idx, comment := range comments {
+		author := l.user(comment.AuthorID)
+
+		//如果是二级以上评论，则需要填充父评论的作者信息
 		if *comment.ParentID != *comment.RootID {
-			//查询父评论的作者简略信息
-			parentUserBriefInfo, err := dao.GetUserBriefInfoByCommentID(*comment.ParentID)
-			if err != nil {
-				zap.L().Error("查询父评论作者信息失败", zap.Error(err))
+			parentAuthorID, ok := parentAuthorIDs[*comment.ParentID]
+			if !ok { // 父评论已被删除
+				zap.L().Error("查询父评论作者信息失败", zap.Int64("parent_id", *comment.ParentID))
 				continue
 			}
+			parentAuthor := l.user(parentAuthorID)
 
 			commentResponse := &response.CommentResponse{
-				ID:        comment.ID,
-				Content:   comment.Content,
-				Author:    userBriefInfo,
-				LikeCount: voteData[idx],
-				Liked:     liked,
-				Parent:    parentUserBriefInfo,
-				CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
-				RootID:    *comment.RootID,
-				ParentID:  *comment.ParentID,
+				ID:            comment.ID,
+				Content:       comment.Content,
+				Author:        &author,
+				LikeCount:     voteData[idx].Ups,
+				DislikeCount:  voteData[idx].Downs,
+				Score:         voteData[idx].Score(),
+				VoteDirection: voteData[idx].Direction,
+				Parent:        &parentAuthor,
+				CreatedAt:     comment.CreatedAt.Format("2006-01-02 15:04:05"),
+				EditedAt:      formatEditedAt(comment.EditedAt),
+				RootID:        *comment.RootID,
+				ParentID:      *comment.ParentID,
 			}
 
+			commentResponse.Mentions = mentions[comment.ID]
+			hideTombstone(commentResponse, comment)
 			commentListResponse.Comments = append(commentListResponse.Comments, commentResponse)
 			continue
 		}
 
 		//如果是二级评论（不展示回复数和父评论作者信息）
 		commentResponse := &response.CommentResponse{
-			ID:        comment.ID,
-			Content:   comment.Content,
-			Author:    userBriefInfo,
-			LikeCount: voteData[idx],
-			Liked:     liked,
-			CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
-			RootID:    *comment.RootID,
+			ID:            comment.ID,
+			Content:       comment.Content,
+			Author:        &author,
+			LikeCount:     voteData[idx].Ups,
+			DislikeCount:  voteData[idx].Downs,
+			Score:         voteData[idx].Score(),
+			VoteDirection: voteData[idx].Direction,
+			CreatedAt:     comment.CreatedAt.Format("2006-01-02 15:04:05"),
+			EditedAt:      formatEditedAt(comment.EditedAt),
+			RootID:        *comment.RootID,
 		}
 
+		commentResponse.Mentions = mentions[comment.ID]
+		hideTombstone(commentResponse, comment)
 		commentListResponse.Comments = append(commentListResponse.Comments, commentResponse)
 	}
 	return
@@ -291,22 +549,31 @@ func GetCommentList(postID int64, listRequest *request.ListRequest, userID int64
 		return nil, err
 	}
 	commentListResponse.Total += topCommentList.Total
+	commentListResponse.NextCursor = topCommentList.NextCursor // 游标按一级评论翻页
+
+	sonListRequest := *listRequest
+	sonListRequest.Cursor = ""
 
 	// 遍历一级评论列表
 	for _, topComment := range topCommentList.Comments {
 		// 封装单个一级评论进响应体
 		commentListResponse.Comments = append(commentListResponse.Comments, &response.CommentResponse{
-			ID:           topComment.ID,
-			Content:      topComment.Content,
-			Author:       topComment.Author,
-			LikeCount:    topComment.LikeCount,
-			Liked:        topComment.Liked,
-			RepliesCount: topComment.RepliesCount,
-			CreatedAt:    topComment.CreatedAt,
+			ID:            topComment.ID,
+			Content:       topComment.Content,
+			Author:        topComment.Author,
+			LikeCount:     topComment.LikeCount,
+			DislikeCount:  topComment.DislikeCount,
+			Score:         topComment.Score,
+			VoteDirection: topComment.VoteDirection,
+			RepliesCount:  topComment.RepliesCount,
+			CreatedAt:     topComment.CreatedAt,
+			EditedAt:      topComment.EditedAt,
+			Deleted:       topComment.Deleted,
+			Mentions:      topComment.Mentions,
 		})
 
-		// 获取单个一级评论的所有二级评论
-		sonCommentList, err := GetSonCommentList(topComment.ID, listRequest, userID)
+		// 获取单个一级评论的所有二级评论（游标只用于一级评论翻页）
+		sonCommentList, err := GetSonCommentList(topComment.ID, &sonListRequest, userID)
 		if err != nil {
 			zap.L().Error("查询帖子的二级评论失败", zap.Error(err))
 			return nil, err
@@ -319,28 +586,38 @@ func GetCommentList(postID int64, listRequest *request.ListRequest, userID int64
 			if sonComment.Parent == nil {
 				// 如果是二级评论
 				commentListResponse.Comments = append(commentListResponse.Comments, &response.CommentResponse{
-					ID:        sonComment.ID,
-					Content:   sonComment.Content,
-					Author:    sonComment.Author,
-					LikeCount: sonComment.LikeCount,
-					Liked:     sonComment.Liked,
-					CreatedAt: sonComment.CreatedAt,
-					RootID:    topComment.ID,
+					ID:            sonComment.ID,
+					Content:       sonComment.Content,
+					Author:        sonComment.Author,
+					LikeCount:     sonComment.LikeCount,
+					DislikeCount:  sonComment.DislikeCount,
+					Score:         sonComment.Score,
+					VoteDirection: sonComment.VoteDirection,
+					CreatedAt:     sonComment.CreatedAt,
+					EditedAt:      sonComment.EditedAt,
+					Deleted:       sonComment.Deleted,
+					Mentions:      sonComment.Mentions,
+					RootID:        topComment.ID,
 				})
 				continue
 			}
 
 			// 如果是二级以上评论
 			commentListResponse.Comments = append(commentListResponse.Comments, &response.CommentResponse{
-				ID:        sonComment.ID,
-				Content:   sonComment.Content,
-				Author:    sonComment.Author,
-				LikeCount: sonComment.LikeCount,
-				Liked:     sonComment.Liked,
-				Parent:    sonComment.Parent,
-				CreatedAt: sonComment.CreatedAt,
-				RootID:    topComment.ID,
-				ParentID:  sonComment.ParentID,
+				ID:            sonComment.ID,
+				Content:       sonComment.Content,
+				Author:        sonComment.Author,
+				LikeCount:     sonComment.LikeCount,
+				DislikeCount:  sonComment.DislikeCount,
+				Score:         sonComment.Score,
+				VoteDirection: sonComment.VoteDirection,
+				Parent:        sonComment.Parent,
+				CreatedAt:     sonComment.CreatedAt,
+				EditedAt:      sonComment.EditedAt,
+				Deleted:       sonComment.Deleted,
+				Mentions:      sonComment.Mentions,
+				RootID:        topComment.ID,
+				ParentID:      sonComment.ParentID,
 			})
 		}
 	}
@@ -0,0 +1,234 @@
+package logic
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+	"vision/models/request"
+	"vision/models/response"
+	"vision/pkg/cursor"
+)
+
+// 评论树的展开层数、每个节点的回复预览数和每页数量的默认值与上限
+const (
+	commentTreeDefaultDepth   = 2
+	commentTreeMaxDepth       = 5
+	commentTreeDefaultPreview = 3
+	commentTreeMaxPreview     = 10
+	commentTreeMaxSize        = 50
+)
+
+// 查询帖子的评论树：分页查询顶级评论（或指定评论的回复），每个节点按 ParentID 向下展开有限层数的回复预览
+// 整页所有节点的投票数据和作者信息各批量查询一次
+func GetCommentTree(postID int64, p *request.CommentTreeRequest, userID int64) (*response.CommentTreeResponse, error) {
+	if p.Depth <= 0 {
+		p.Depth = commentTreeDefaultDepth
+	}
+	if p.Depth > commentTreeMaxDepth {
+		p.Depth = commentTreeMaxDepth
+	}
+	if p.Preview <= 0 {
+		p.Preview = commentTreeDefaultPreview
+	}
+	if p.Preview > commentTreeMaxPreview {
+		p.Preview = commentTreeMaxPreview
+	}
+	if p.Size <= 0 {
+		p.Size = 10
+	}
+	if p.Size > commentTreeMaxSize {
+		p.Size = commentTreeMaxSize
+	}
+
+	if _, err := dao.GetPublishedPostById(postID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return nil, constants.ErrorNoPost
+		}
+		return nil, err
+	}
+
+	treeResponse := &response.CommentTreeResponse{
+		Comments: []*response.CommentTreeNode{},
+	}
+
+	// 查询本页的评论：未指定 parent_id 时按排序方式查询顶级评论，否则按发布时间正序查询该评论的回复
+	var comments []*entity.Comment
+	if p.ParentID == 0 {
+		ids, total, next, err := redis.GetTopCommentIDsInOrder(&p.ListRequest, postID)
+		if err != nil {
+			return nil, err
+		}
+		treeResponse.Total = total
+		treeResponse.NextCursor = next
+		if comments, err = dao.GetCommentListByIDs(ids); err != nil {
+			return nil, err
+		}
+	} else {
+		parent, err := dao.GetCommentByID(p.ParentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到评论
+				return nil, constants.ErrorNoComment
+			}
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, constants.ErrorNoComment
+		}
+		counts, err := dao.CountCommentReplies([]int64{parent.ID})
+		if err != nil {
+			return nil, err
+		}
+		treeResponse.Total = counts[parent.ID]
+		if comments, treeResponse.NextCursor, err = getCommentReplyPage(parent.ID, p.Cursor, p.Size); err != nil {
+			return nil, err
+		}
+	}
+	if len(comments) == 0 {
+		return treeResponse, nil
+	}
+
+	// 逐层展开回复：每层用一次查询统计回复数、一次查询取出回复预览
+	var all []*entity.Comment
+	nodes := make(map[int64]*response.CommentTreeNode)
+	level := comments
+	for _, comment := range comments {
+		node := newCommentTreeNode(comment)
+		nodes[comment.ID] = node
+		treeResponse.Comments = append(treeResponse.Comments, node)
+	}
+	for depth := 0; len(level) > 0; depth++ {
+		all = append(all, level...)
+		parentIDs := make([]int64, len(level))
+		for i, comment := range level {
+			parentIDs[i] = comment.ID
+		}
+		counts, err := dao.CountCommentReplies(parentIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		// 已到达展开层数，只标记是否有回复
+		if depth == p.Depth {
+			for _, comment := range level {
+				node := nodes[comment.ID]
+				count := counts[comment.ID]
+				node.RepliesCount = &count
+				node.HasMoreReplies = count > 0
+			}
+			break
+		}
+
+		previews, err := dao.GetCommentReplyPreviews(parentIDs, p.Preview)
+		if err != nil {
+			return nil, err
+		}
+		lastReplies := make(map[int64]*entity.Comment, len(level)) // 每个父评论已返回的最后一条回复
+		for _, reply := range previews {
+			node := newCommentTreeNode(reply)
+			nodes[reply.ID] = node
+			parent := nodes[*reply.ParentID]
+			parent.Replies = append(parent.Replies, node)
+			lastReplies[*reply.ParentID] = reply
+		}
+		for _, comment := range level {
+			node := nodes[comment.ID]
+			count := counts[comment.ID]
+			node.RepliesCount = &count
+			if count > int64(len(node.Replies)) {
+				node.HasMoreReplies = true
+				if last, ok := lastReplies[comment.ID]; ok {
+					node.NextCursor = replyCursor(last)
+				}
+			}
+		}
+		level = previews
+	}
+
+	// 批量查询整页评论的投票数据（包含当前用户的投票方向）和作者信息
+	ids := make([]string, len(all))
+	authorIDs := make([]int64, len(all))
+	for i, comment := range all {
+		ids[i] = strconv.FormatInt(comment.ID, 10)
+		authorIDs[i] = comment.AuthorID
+	}
+	l := newLoader(userID)
+	voteData, err := l.commentVotes(ids)
+	if err != nil {
+		return nil, err
+	}
+	l.loadUsers(authorIDs)
+	mentions := getCommentMentions(commentIDsOf(all))
+
+	for i, comment := range all {
+		node := nodes[comment.ID]
+		author := l.user(comment.AuthorID)
+		node.Author = &author
+		node.LikeCount = voteData[i].Ups
+		node.DislikeCount = voteData[i].Downs
+		node.Score = voteData[i].Score()
+		node.VoteDirection = voteData[i].Direction
+		node.Mentions = mentions[comment.ID]
+		hideTombstone(&node.CommentResponse, comment)
+	}
+	return treeResponse, nil
+}
+
+// 创建评论树节点，投票数据和作者信息在整页加载完成后统一填充
+func newCommentTreeNode(comment *entity.Comment) *response.CommentTreeNode {
+	node := &response.CommentTreeNode{
+		CommentResponse: response.CommentResponse{
+			ID:        comment.ID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
+			EditedAt:  formatEditedAt(comment.EditedAt),
+		},
+		Replies: []*response.CommentTreeNode{},
+	}
+	if comment.RootID != nil {
+		node.RootID = *comment.RootID
+	}
+	if comment.ParentID != nil {
+		node.ParentID = *comment.ParentID
+	}
+	return node
+}
+
+// 回复按发布时间正序分页，游标记录最后一条回复的发布时间（微秒）和id
+func replyCursor(comment *entity.Comment) string {
+	return cursor.Encode(float64(comment.CreatedAt.UnixMicro()), strconv.FormatInt(comment.ID, 10))
+}
+
+// 按游标分页查询评论的直接回复，返回本页回复和下一页的游标
+func getCommentReplyPage(parentID int64, token string, size int64) ([]*entity.Comment, string, error) {
+	var after *time.Time
+	var afterID int64
+	if token != "" {
+		c, err := cursor.Decode(token)
+		if err != nil {
+			return nil, "", constants.ErrorInvalidParam
+		}
+		afterID, err = strconv.ParseInt(c.Member, 10, 64)
+		if err != nil {
+			return nil, "", constants.ErrorInvalidParam
+		}
+		t := time.UnixMicro(int64(c.Score))
+		after = &t
+	}
+
+	comments, err := dao.GetCommentRepliesAfter(parentID, after, afterID, size)
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(comments)) <= size {
+		return comments, "", nil
+	}
+	comments = comments[:size]
+	return comments, replyCursor(comments[len(comments)-1]), nil
+}
@@ -0,0 +1,190 @@
+package logic
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/models/entity"
+	"vision/models/request"
+	"vision/models/response"
+	"vision/pkg/hashtag"
+	"vision/service/mention"
+	"vision/service/publish"
+)
+
+// 解析请求中的时间参数（定时发布时间、置顶到期时间等），为空时返回 nil
+func parseDateTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		return nil, constants.ErrorInvalidParam
+	}
+	return &t, nil
+}
+
+// 根据请求确定帖子的发布状态：发布时间在未来则定时发布，否则按是否为草稿决定
+func resolvePublishState(draft bool, publishAt string) (string, *time.Time, error) {
+	t, err := parseDateTime(publishAt)
+	if err != nil {
+		return "", nil, err
+	}
+	if t != nil && t.After(time.Now()) {
+		return entity.PostStatusScheduled, t, nil
+	}
+	if draft {
+		return entity.PostStatusDraft, nil, nil
+	}
+	return entity.PostStatusPublished, nil, nil
+}
+
+// 格式化帖子的定时发布时间，未设置返回空字符串
+func formatPublishAt(post *entity.Post) string {
+	if post.PublishAt == nil {
+		return ""
+	}
+	return post.PublishAt.Format("2006-01-02 15:04:05")
+}
+
+// 查询作者本人尚未发布的帖子
+func getUserDraft(postID, userID int64) (*entity.Post, error) {
+	post, err := dao.GetPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return nil, constants.ErrorNoPost
+		}
+		return nil, err
+	}
+	if post.AuthorID != userID {
+		return nil, constants.ErrorNoPermission
+	}
+	if post.Status == entity.PostStatusPublished {
+		return nil, constants.ErrorPostPublished
+	}
+	return post, nil
+}
+
+// 查询用户的草稿和定时发布的帖子
+func GetUserDraftList(userID int64, listRequest *request.ListRequest) (*response.PostListResponse, error) {
+	postListResponse := &response.PostListResponse{
+		Posts: []*response.PostResponse{},
+	}
+
+	posts, total, err := dao.GetUserDrafts(userID, listRequest.Page, listRequest.Size)
+	if err != nil {
+		return nil, err
+	}
+	postListResponse.Total = total
+	if len(posts) == 0 {
+		return postListResponse, nil
+	}
+
+	postListResponse.Posts = getDraftResponses(posts)
+	return postListResponse, nil
+}
+
+// 修改草稿内容和发布计划
+func UpdateDraft(postID, userID int64, p *request.UpdateDraftRequest) (*response.PostResponse, error) {
+	post, err := getUserDraft(postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 带未来的发布时间则定时发布，否则保存为草稿
+	status, publishAt, err := resolvePublishState(true, p.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.UpdateDraft(postID, p.Content, p.Image, status, publishAt); err != nil {
+		return nil, err
+	}
+	// 绑定新图片对应的附件，避免被当作未使用的附件清理
+	if err := dao.BindImageAttachment(postID, userID, p.Image); err != nil {
+		return nil, err
+	}
+
+	// 草稿的标签只保存在数据库，发布时才写入 redis
+	if _, _, err := dao.ReplacePostTags(postID, hashtag.FromPost(p.Content, p.Tags)); err != nil {
+		return nil, err
+	}
+
+	// 草稿的提及只保存，发布时才通知被提及的用户
+	if _, err := mention.Save(postID, 0, userID, p.Content); err != nil { // 草稿已经保存，遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("保存提及失败", zap.Int64("post_id", postID), zap.Error(err))
+	}
+
+	post.Content = p.Content
+	post.Image = p.Image
+	post.Status = status
+	post.PublishAt = publishAt
+	return getDraftResponses([]*entity.Post{post})[0], nil
+}
+
+// 发布草稿：指定未来的发布时间则改为定时发布，否则立即发布
+func PublishDraft(postID, userID int64, p *request.PublishDraftRequest) (*response.PostResponse, error) {
+	post, err := getUserDraft(postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	publishAt, err := parseDateTime(p.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+	if publishAt != nil && publishAt.After(time.Now()) {
+		if err := dao.ScheduleDraft(postID, *publishAt); err != nil {
+			return nil, err
+		}
+		post.Status = entity.PostStatusScheduled
+		post.PublishAt = publishAt
+		return getDraftResponses([]*entity.Post{post})[0], nil
+	}
+
+	if err := publish.Publish(post); err != nil {
+		return nil, err
+	}
+	return GetPostDetail(postID, userID)
+}
+
+// 封装草稿的响应数据（草稿没有投票和评论数据）
+func getDraftResponses(posts []*entity.Post) []*response.PostResponse {
+	l := newLoader(0)
+	postIDs := make([]int64, len(posts))
+	authorIDs := make([]int64, len(posts))
+	communityIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+		authorIDs[i] = post.AuthorID
+		communityIDs[i] = post.CommunityID
+	}
+	l.loadUsers(authorIDs)
+	l.loadCommunities(communityIDs)
+	tags := getPostTags(postIDs)
+	mentions := getPostMentions(postIDs)
+	attachments := getPostAttachments(postIDs)
+
+	postResponses := make([]*response.PostResponse, 0, len(posts))
+	for _, post := range posts {
+		postResponses = append(postResponses, &response.PostResponse{
+			ID:            post.ID,
+			Content:       post.Content,
+			Image:         post.Image,
+			Author:        l.user(post.AuthorID),
+			CreatedAt:     post.CreatedAt.Format("2006-01-02 15:04:05"),
+			RevisionCount: post.RevisionCount,
+			Community:     l.community(post.CommunityID),
+			Tags:          tags[post.ID],
+			Mentions:      mentions[post.ID],
+			Attachments:   attachments[post.ID],
+			Status:        post.Status,
+			PublishAt:     formatPublishAt(post),
+		})
+	}
+	return postResponses
+}
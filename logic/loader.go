@@ -0,0 +1,125 @@
+package logic
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/response"
+)
+
+// 用户和社区简略信息的进程内缓存，列表接口大量重复查询同一批作者和社区
+// 缓存时间较短，用户修改资料后最多延迟一个缓存周期生效（修改资料时会主动清除）
+const briefCacheTTL = time.Minute
+
+var (
+	userBriefCache      = cache.New(briefCacheTTL, 2*briefCacheTTL)
+	communityBriefCache = cache.New(briefCacheTTL, 2*briefCacheTTL)
+)
+
+// 请求级别的批量加载器：先收集一页数据需要的用户和社区id，再用一次 IN 查询批量加载，
+// 投票数据和当前用户的投票方向通过一次 redis pipeline 查询，避免每条数据单独查询
+type loader struct {
+	userID      int64 // 当前用户，0 表示游客
+	users       map[int64]response.UserBriefResponse
+	communities map[int64]response.CommunityBriefResponse
+}
+
+func newLoader(userID int64) *loader {
+	return &loader{
+		userID:      userID,
+		users:       make(map[int64]response.UserBriefResponse),
+		communities: make(map[int64]response.CommunityBriefResponse),
+	}
+}
+
+// 批量加载用户简略信息，优先使用缓存
+func (l *loader) loadUsers(ids []int64) {
+	var missing []int64
+	for _, id := range ids {
+		if _, ok := l.users[id]; ok {
+			continue
+		}
+		if cached, ok := userBriefCache.Get(strconv.FormatInt(id, 10)); ok {
+			l.users[id] = cached.(response.UserBriefResponse)
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	users, err := dao.GetUserBriefInfos(missing)
+	if err != nil { // 遇到错误不返回，缺失的用户只返回id
+		zap.L().Error("批量查询用户信息失败", zap.Error(err))
+		return
+	}
+	for _, user := range users {
+		l.users[user.ID] = *user
+		userBriefCache.SetDefault(strconv.FormatInt(user.ID, 10), *user)
+	}
+}
+
+// 批量加载社区简略信息，优先使用缓存
+func (l *loader) loadCommunities(ids []int64) {
+	var missing []int64
+	for _, id := range ids {
+		if _, ok := l.communities[id]; ok {
+			continue
+		}
+		if cached, ok := communityBriefCache.Get(strconv.FormatInt(id, 10)); ok {
+			l.communities[id] = cached.(response.CommunityBriefResponse)
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	communities, err := dao.GetCommunityBriefs(missing)
+	if err != nil { // 遇到错误不返回，缺失的社区只返回id
+		zap.L().Error("批量查询社区信息失败", zap.Error(err))
+		return
+	}
+	for _, community := range communities {
+		l.communities[community.ID] = *community
+		communityBriefCache.SetDefault(strconv.FormatInt(community.ID, 10), *community)
+	}
+}
+
+// 取出已加载的用户简略信息，未加载到时只包含id
+func (l *loader) user(id int64) response.UserBriefResponse {
+	if user, ok := l.users[id]; ok {
+		return user
+	}
+	return response.UserBriefResponse{ID: id}
+}
+
+// 取出已加载的社区简略信息，未加载到时只包含id
+func (l *loader) community(id int64) response.CommunityBriefResponse {
+	if community, ok := l.communities[id]; ok {
+		return community
+	}
+	return response.CommunityBriefResponse{ID: id}
+}
+
+// 批量查询帖子的投票数据和当前用户的投票方向
+func (l *loader) postVotes(ids []string) ([]redis.VoteData, error) {
+	return getPostVoteData(ids, l.userID)
+}
+
+// 批量查询评论的投票数据和当前用户的投票方向
+func (l *loader) commentVotes(ids []string) ([]redis.VoteData, error) {
+	return redis.GetCommentVoteDataByIDs(ids, commentVoter(l.userID))
+}
+
+// 用户修改资料后清除缓存的简略信息
+func forgetUserBrief(userID int64) {
+	userBriefCache.Delete(strconv.FormatInt(userID, 10))
+}
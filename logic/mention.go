@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/models/entity"
+	"vision/models/response"
+)
+
+// 转换提及记录为响应数据
+func toMentionResponses(mentions []*entity.Mention) []*response.MentionResponse {
+	responses := make([]*response.MentionResponse, 0, len(mentions))
+	for _, m := range mentions {
+		responses = append(responses, &response.MentionResponse{
+			UserID:   m.UserID,
+			Username: m.Username,
+			Start:    m.Start,
+			End:      m.End,
+		})
+	}
+	return responses
+}
+
+// 批量查询帖子正文中的提及，查询失败时返回空 map
+func getPostMentions(postIDs []int64) map[int64][]*response.MentionResponse {
+	mentions, err := dao.GetPostMentions(postIDs)
+	if err != nil { // 遇到错误不返回，按无提及处理
+		zap.L().Error("查询帖子提及失败", zap.Error(err))
+		return map[int64][]*response.MentionResponse{}
+	}
+	byPost := make(map[int64][]*entity.Mention)
+	for _, m := range mentions {
+		byPost[m.PostID] = append(byPost[m.PostID], m)
+	}
+	responses := make(map[int64][]*response.MentionResponse, len(byPost))
+	for postID, ms := range byPost {
+		responses[postID] = toMentionResponses(ms)
+	}
+	return responses
+}
+
+// 批量查询评论中的提及，查询失败时返回空 map
+func getCommentMentions(commentIDs []int64) map[int64][]*response.MentionResponse {
+	mentions, err := dao.GetCommentMentions(commentIDs)
+	if err != nil { // 遇到错误不返回，按无提及处理
+		zap.L().Error("查询评论提及失败", zap.Error(err))
+		return map[int64][]*response.MentionResponse{}
+	}
+	byComment := make(map[int64][]*entity.Mention)
+	for _, m := range mentions {
+		byComment[m.CommentID] = append(byComment[m.CommentID], m)
+	}
+	responses := make(map[int64][]*response.MentionResponse, len(byComment))
+	for commentID, ms := range byComment {
+		responses[commentID] = toMentionResponses(ms)
+	}
+	return responses
+}
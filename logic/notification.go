@@ -0,0 +1,53 @@
+package logic
+
+import (
+	"vision/dao"
+	"vision/models/request"
+	"vision/models/response"
+)
+
+// 分页查询用户的通知（按时间倒序），同时返回未读通知数
+func GetUserNotificationList(userID int64, listRequest *request.ListRequest) (*response.NotificationListResponse, error) {
+	if listRequest.Size > 100 {
+		listRequest.Size = 100
+	}
+	notificationListResponse := &response.NotificationListResponse{
+		Notifications: []*response.NotificationResponse{},
+	}
+
+	notifications, total, err := dao.GetUserNotifications(userID, listRequest.Page, listRequest.Size)
+	if err != nil {
+		return nil, err
+	}
+	notificationListResponse.Total = total
+	if notificationListResponse.Unread, err = dao.CountUnreadNotifications(userID); err != nil {
+		return nil, err
+	}
+
+	// 批量加载触发通知的用户信息
+	l := newLoader(userID)
+	actorIDs := make([]int64, len(notifications))
+	for i, notification := range notifications {
+		actorIDs[i] = notification.ActorID
+	}
+	l.loadUsers(actorIDs)
+
+	for _, notification := range notifications {
+		notificationListResponse.Notifications = append(notificationListResponse.Notifications, &response.NotificationResponse{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			Actor:     l.user(notification.ActorID),
+			PostID:    notification.PostID,
+			CommentID: notification.CommentID,
+			Read:      notification.ReadAt != nil,
+			CreatedAt: notification.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return notificationListResponse, nil
+}
+
+// 把用户的所有未读通知标记为已读
+func ReadAllNotifications(userID int64) error {
+	_, err := dao.MarkNotificationsRead(userID)
+	return err
+}
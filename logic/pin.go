@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/models/entity"
+	"vision/models/request"
+	"vision/models/response"
+)
+
+// 每个社区（以及全站）最多同时置顶的帖子数量
+const MaxPinnedPosts = 5
+
+// 置顶帖子：全站置顶仅管理员可操作，社区置顶管理员和该社区版主可操作
+func PinPost(postID, userID int64, p *request.PinPostRequest) error {
+	post, err := dao.GetPublishedPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return constants.ErrorNoPost
+		}
+		return err
+	}
+
+	communityID, err := getPinScope(post, userID, p.Scope)
+	if err != nil {
+		return err
+	}
+
+	expiresAt, err := parseDateTime(p.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return constants.ErrorInvalidParam
+	}
+
+	// 先清理已到期的置顶，再检查数量上限并置顶（重复置顶同一帖子只更新到期时间）
+	if err := dao.DeleteExpiredPins(now); err != nil {
+		return err
+	}
+	return dao.PinPost(&entity.PostPin{
+		CommunityID: communityID,
+		PostID:      postID,
+		PinnedBy:    userID,
+		ExpiresAt:   expiresAt,
+	}, MaxPinnedPosts, now)
+}
+
+// 取消置顶帖子
+func UnpinPost(postID, userID int64, scope string) error {
+	post, err := dao.GetPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return constants.ErrorNoPost
+		}
+		return err
+	}
+
+	communityID, err := getPinScope(post, userID, scope)
+	if err != nil {
+		return err
+	}
+	return dao.UnpinPost(communityID, postID)
+}
+
+// 校验用户的置顶权限，返回置顶范围对应的社区id（0 表示全站）
+func getPinScope(post *entity.Post, userID int64, scope string) (int64, error) {
+	user, err := dao.GetUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.Role == constants.RoleAdmin {
+		if scope == constants.PinScopeGlobal {
+			return 0, nil
+		}
+		return post.CommunityID, nil
+	}
+
+	// 非管理员只能置顶到自己管理的社区
+	if scope == constants.PinScopeGlobal {
+		return 0, constants.ErrorNoPermission
+	}
+	isModerator, err := dao.IsCommunityModerator(post.CommunityID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !isModerator {
+		return 0, constants.ErrorNoPermission
+	}
+	return post.CommunityID, nil
+}
+
+// 把置顶帖子放到帖子列表最前面：第一页在常规列表前插入置顶帖子，每一页都从常规列表中去掉置顶帖子，
+// 置顶帖子在整个列表中只出现一次，Total 包含置顶帖子（置顶帖子本身也在常规列表的集合中）
+// 去掉置顶帖子后常规列表的页可能少于 size 条，分页游标仍按常规列表计算
+// communityID 为 0 表示全站列表
+func withPinnedPosts(postListResponse *response.PostListResponse, firstPage bool, communityID, userID int64) {
+	pinnedIDs, err := dao.GetActivePinnedPostIDs(communityID, time.Now())
+	if err != nil { // 遇到错误不返回，按无置顶处理
+		zap.L().Error("查询置顶帖子失败", zap.Error(err))
+		return
+	}
+	if len(pinnedIDs) == 0 {
+		return
+	}
+
+	pinned := make(map[int64]struct{}, len(pinnedIDs))
+	for _, id := range pinnedIDs {
+		postID, _ := strconv.ParseInt(id, 10, 64)
+		pinned[postID] = struct{}{}
+	}
+
+	posts := make([]*response.PostResponse, 0, len(pinnedIDs)+len(postListResponse.Posts))
+	if firstPage {
+		pinnedPosts, err := GetPostListByIDs(pinnedIDs, userID)
+		if err != nil { // 遇到错误不返回，按无置顶处理
+			zap.L().Error("查询置顶帖子详情失败", zap.Error(err))
+			return
+		}
+		for _, post := range pinnedPosts {
+			post.Pinned = true
+			posts = append(posts, post)
+		}
+	}
+	for _, post := range postListResponse.Posts {
+		if _, ok := pinned[post.ID]; !ok {
+			posts = append(posts, post)
+		}
+	}
+	postListResponse.Posts = posts
+}
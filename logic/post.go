@@ -6,8 +6,12 @@ import (
 	"time"
 	"vision/dao"
 	"vision/models/proto"
+	"vision/pkg/diff"
+	"vision/pkg/hashtag"
 	"vision/pkg/snowflake"
 	"vision/service/kafka"
+	"vision/service/mention"
+	"vision/service/ranking"
 
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -31,12 +35,30 @@ func CreatePost(createPostRequest *request.CreatePostRequest, authorID int64) (p
 		CommunityID: createPostRequest.CommunityID,
 	}
 
-	//保存到数据库
-	err = dao.CreatePost(post)
+	//确定发布状态，草稿和定时发布的帖子只保存到数据库
+	post.Status, post.PublishAt, err = resolvePublishState(createPostRequest.Draft, createPostRequest.PublishAt)
 	if err != nil {
 		return
 	}
 
+	//解析话题标签
+	tags := hashtag.FromPost(createPostRequest.Content, createPostRequest.Tags)
+
+	//保存到数据库，同时按顺序绑定上传的附件
+	err = dao.CreatePostWithAttachments(post, createPostRequest.Attachments)
+	if err != nil {
+		return
+	}
+	if err = dao.SavePostTags(post.ID, tags); err != nil {
+		return
+	}
+
+	//解析并保存 @提及
+	mentions, mentionErr := mention.Save(post.ID, 0, authorID, post.Content)
+	if mentionErr != nil { // 帖子已经保存，遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("保存提及失败", zap.Int64("post_id", post.ID), zap.Error(mentionErr))
+	}
+
 	//查询作者简略信息
 	userBriefInfo, err := dao.GetUserBriefInfo(post.AuthorID)
 	if err != nil { // 遇到错误不返回，继续执行后续逻辑
@@ -51,16 +73,39 @@ func CreatePost(createPostRequest *request.CreatePostRequest, authorID int64) (p
 
 	//封装查询到的信息
 	postResponse = &response.PostResponse{
-		ID:        post.ID,
-		Content:   post.Content,
-		Image:     post.Image,
-		Author:    *userBriefInfo,
-		CreatedAt: post.CreatedAt.Format("2006-01-02 15:04:05"),
-		Community: response.CommunityBriefResponse{ID: community.ID, CommunityName: community.CommunityName},
+		ID:          post.ID,
+		Content:     post.Content,
+		Image:       post.Image,
+		Author:      *userBriefInfo,
+		CreatedAt:   post.CreatedAt.Format("2006-01-02 15:04:05"),
+		Community:   response.CommunityBriefResponse{ID: community.ID, CommunityName: community.CommunityName},
+		Tags:        tags,
+		Mentions:    toMentionResponses(mentions),
+		Attachments: getPostAttachments([]int64{post.ID})[post.ID],
+	}
+
+	//草稿和定时发布的帖子到发布时才写入redis
+	if post.Status != entity.PostStatusPublished {
+		postResponse.Status = post.Status
+		postResponse.PublishAt = formatPublishAt(post)
+		return
 	}
 
 	//保存到redis
-	err = redis.CreatePost(post.ID, post.CommunityID)
+	if err = redis.CreatePost(post.ID, post.CommunityID); err != nil {
+		return
+	}
+	if err = redis.AddPostTags(post.ID, tags, post.CreatedAt); err != nil {
+		return
+	}
+
+	//通知被提及的用户
+	if err := mention.Notify(mentions); err != nil { // 遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("发送提及通知失败", zap.Error(err))
+	}
+
+	//按当前排序算法计算初始热度
+	err = ranking.RefreshPost(post)
 	return
 }
 
@@ -95,13 +140,20 @@ func CreatePostAsyncUseJSON(createPostRequest *request.CreatePostRequest, author
 		Author:    response.UserBriefResponse{ID: authorID},
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
 		Community: response.CommunityBriefResponse{ID: createPostRequest.CommunityID},
+		Tags:      hashtag.FromPost(createPostRequest.Content, nil), // 异步消息中不携带标签，消费者从内容中解析
 	}
 
 	return postResponse, nil
 }
 
-// logic/post.go
+// 异步创建帖子：预先生成帖子 ID 并发送 kafka 消息，由消费者写入，客户端通过 GetPostCreationStatus 轮询结果
 func CreatePostAsync(createPostRequest *request.CreatePostRequest, authorID int64) (*response.PostResponse, error) {
+	// 消息中不携带草稿、定时发布、附件和额外标签，这类帖子直接同步创建
+	if createPostRequest.Draft || createPostRequest.PublishAt != "" ||
+		len(createPostRequest.Attachments) > 0 || len(createPostRequest.Tags) > 0 {
+		return CreatePost(createPostRequest, authorID)
+	}
+
 	// 生成帖子 ID
 	postID := snowflake.GenID()
 
@@ -119,8 +171,16 @@ func CreatePostAsync(createPostRequest *request.CreatePostRequest, authorID int6
 		PostId: postID,
 	}
 
+	// 先记录等待状态再发送，避免消费者写入完成后状态被覆盖
+	if err := redis.SetPostCreationStatus(postID, authorID, constants.PostCreationPending, ""); err != nil {
+		return nil, err
+	}
+
 	// 发送 Kafka 消息
 	if err := kafka.SendPostCreationMessage(message); err != nil {
+		if err := redis.SetPostCreationStatus(postID, authorID, constants.PostCreationFailed, "发送消息失败"); err != nil {
+			zap.L().Error("更新异步发帖状态失败", zap.Int64("post_id", postID), zap.Error(err))
+		}
 		return nil, err
 	}
 
@@ -132,11 +192,39 @@ func CreatePostAsync(createPostRequest *request.CreatePostRequest, authorID int6
 		Author:    response.UserBriefResponse{ID: authorID},
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
 		Community: response.CommunityBriefResponse{ID: createPostRequest.CommunityID},
+		Tags:      hashtag.FromPost(createPostRequest.Content, nil), // 异步消息中不携带标签，消费者从内容中解析
 	}
 
 	return postResponse, nil
 }
 
+// 查询异步发帖状态，只有作者本人可以查询
+// 状态记录过期或帖子是同步创建的，则以数据库中是否存在帖子为准
+func GetPostCreationStatus(postID, userID int64) (*response.PostCreationStatusResponse, error) {
+	status, err := redis.GetPostCreationStatus(postID)
+	if err != nil {
+		return nil, err
+	}
+	if status != nil {
+		if status.AuthorID != userID {
+			return nil, constants.ErrorNoPost
+		}
+		return &response.PostCreationStatusResponse{ID: postID, Status: status.Status, Reason: status.Reason}, nil
+	}
+
+	post, err := dao.GetPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return nil, constants.ErrorNoPost
+		}
+		return nil, err
+	}
+	if post.AuthorID != userID {
+		return nil, constants.ErrorNoPost
+	}
+	return &response.PostCreationStatusResponse{ID: postID, Status: constants.PostCreationCreated}, nil
+}
+
 // 删除帖子
 func DeletePost(postID int64, userID int64) error {
 	// 从mysql查询帖子
@@ -153,6 +241,12 @@ func DeletePost(postID int64, userID int64) error {
 	}
 	communityID := post.CommunityID
 
+	// 查询点赞过该帖子的用户，删除后需要从他们的点赞记录中移除
+	likerIDs, err := dao.GetPostLikerIDs(postID)
+	if err != nil {
+		return err
+	}
+
 	// 删除mysql中的帖子
 	if err := dao.DeletePost(postID); err != nil {
 		return err
@@ -162,10 +256,258 @@ func DeletePost(postID int64, userID int64) error {
 	if err := redis.DeletePost(postID, communityID); err != nil {
 		return err
 	}
+	if err := redis.RemoveLikedPost(strconv.FormatInt(postID, 10), likerIDs); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// 编辑帖子（仅作者本人可编辑），旧内容保存为历史版本
+func UpdatePost(postID int64, userID int64, updatePostRequest *request.UpdatePostRequest) (*response.PostResponse, error) {
+	// 从数据库查询帖子
+	post, err := dao.GetPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return nil, constants.ErrorNoPost
+		}
+		return nil, err
+	}
+	// 校验userID
+	if post.AuthorID != userID {
+		return nil, constants.ErrorNoPermission
+	}
+	// 草稿和定时帖子通过草稿接口修改
+	if post.Status != entity.PostStatusPublished {
+		return nil, constants.ErrorPostNotPublished
+	}
+
+	// 保存历史版本并更新帖子
+	if err := dao.UpdatePost(post, updatePostRequest.Content, updatePostRequest.Image, userID); err != nil {
+		return nil, err
+	}
+
+	// 按新内容替换话题标签
+	tags := hashtag.FromPost(updatePostRequest.Content, updatePostRequest.Tags)
+	removed, added, err := dao.ReplacePostTags(postID, tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := redis.UpdatePostTags(postID, removed, added); err != nil {
+		return nil, err
+	}
+
+	// 按新内容重新保存 @提及，只通知新增的被提及用户
+	mentions, err := mention.Save(postID, 0, post.AuthorID, updatePostRequest.Content)
+	if err != nil { // 帖子已经更新，遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("保存提及失败", zap.Int64("post_id", postID), zap.Error(err))
+	}
+	if err := mention.Notify(mentions); err != nil { // 遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("发送提及通知失败", zap.Error(err))
+	}
+
+	// 复用列表的封装逻辑，返回最新的帖子数据
+	postResponses, err := GetPostListByIDs([]string{strconv.FormatInt(postID, 10)}, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(postResponses) == 0 {
+		return nil, constants.ErrorNoPost
+	}
+	return postResponses[0], nil
+}
+
+// 查询帖子的历史版本，每个版本附带与下一个版本的差异
+func GetPostRevisionList(postID int64) (*response.PostRevisionListResponse, error) {
+	post, err := dao.GetPublishedPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return nil, constants.ErrorNoPost
+		}
+		return nil, err
+	}
+
+	revisions, err := dao.GetPostRevisions(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisionListResponse := &response.PostRevisionListResponse{
+		PostID:    postID,
+		Revisions: []*response.PostRevisionResponse{},
+		Total:     int64(len(revisions)),
+	}
+
+	// 版本按倒序排列，第一个版本的“下一个版本”就是帖子当前内容
+	next := post.Content
+	l := newLoader(0)
+	editorIDs := make([]int64, len(revisions))
+	for i, revision := range revisions {
+		editorIDs[i] = revision.EditorID
+	}
+	l.loadUsers(editorIDs)
+	for _, revision := range revisions {
+		revisionListResponse.Revisions = append(revisionListResponse.Revisions, &response.PostRevisionResponse{
+			Version:   revision.Version,
+			Content:   revision.Content,
+			Image:     revision.Image,
+			Editor:    l.user(revision.EditorID),
+			CreatedAt: revision.CreatedAt.Format("2006-01-02 15:04:05"),
+			Diff:      diff.Lines(revision.Content, next),
+		})
+		next = revision.Content
+	}
+	return revisionListResponse, nil
+}
+
+// 查询单个帖子详情（包含点赞数、评论数和当前用户的点赞状态）
+func GetPostDetail(postID int64, userID int64) (*response.PostResponse, error) {
+	// 从数据库查询帖子
+	post, err := dao.GetPostById(postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 如果查询不到帖子
+			return nil, constants.ErrorNoPost
+		}
+		return nil, err
+	}
+	// 未发布的帖子仅作者本人可见
+	if post.Status != entity.PostStatusPublished {
+		if post.AuthorID != userID {
+			return nil, constants.ErrorNoPost
+		}
+		return getDraftResponses([]*entity.Post{post})[0], nil
+	}
+	postIDStr := strconv.FormatInt(post.ID, 10)
+
+	// 查询投票数据和评论数
+	voteData, err := getPostVoteData([]string{postIDStr}, userID)
+	if err != nil {
+		return nil, err
+	}
+	commentNum, err := redis.GetCommentNumByIDs([]string{postIDStr})
+	if err != nil {
+		return nil, err
+	}
+
+	// 查询当前用户是否已收藏
+	bookmarked := getBookmarkedPosts(userID, []int64{post.ID})
+
+	// 查询话题标签、提及和附件
+	tags := getPostTags([]int64{post.ID})
+	mentions := getPostMentions([]int64{post.ID})
+	attachments := getPostAttachments([]int64{post.ID})
+
+	// 查询作者简略信息
+	author := response.UserBriefResponse{ID: post.AuthorID}
+	userBriefInfo, err := dao.GetUserBriefInfo(post.AuthorID)
+	if err != nil { // 遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("查询作者信息失败", zap.Error(err))
+	} else {
+		author = *userBriefInfo
+	}
+
+	// 查询社区详情
+	communityBrief := response.CommunityBriefResponse{ID: post.CommunityID}
+	community, err := dao.GetCommunityById(post.CommunityID)
+	if err != nil { // 遇到错误不返回，继续执行后续逻辑
+		zap.L().Error("查询社区详情失败", zap.Error(err))
+	} else {
+		communityBrief.CommunityName = community.CommunityName
+	}
+
+	return &response.PostResponse{
+		ID:            post.ID,
+		Content:       post.Content,
+		Image:         post.Image,
+		Author:        author,
+		LikeCount:     voteData[0].Ups,
+		DislikeCount:  voteData[0].Downs,
+		Score:         voteData[0].Score(),
+		VoteDirection: voteData[0].Direction,
+		Bookmarked:    bookmarked[post.ID],
+		CommentCount:  int64(commentNum[0]),
+		CreatedAt:     post.CreatedAt.Format("2006-01-02 15:04:05"),
+		EditedAt:      formatEditedAt(post.EditedAt),
+		RevisionCount: post.RevisionCount,
+		Community:     communityBrief,
+		Tags:          tags[post.ID],
+		Mentions:      mentions[post.ID],
+		Attachments:   attachments[post.ID],
+	}, nil
+}
+
+// 批量查询帖子的投票数据（包含当前用户的投票方向），userID 为0表示游客
+func getPostVoteData(ids []string, userID int64) ([]redis.VoteData, error) {
+	userIDStr := ""
+	if userID != 0 {
+		userIDStr = strconv.FormatInt(userID, 10)
+	}
+
+	// 票数和未归档帖子的投票方向在同一个 pipeline 中查询
+	voteData, err := redis.GetPostVoteDataByIDs(ids, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 {
+		return voteData, nil
+	}
+
+	// 已归档帖子在 redis 中没有投票记录，当前用户的投票方向从数据库批量查询
+	var archivedIDs []int64
+	for i, id := range ids {
+		if voteData[i].Archived {
+			postID, _ := strconv.ParseInt(id, 10, 64)
+			archivedIDs = append(archivedIDs, postID)
+		}
+	}
+	if len(archivedIDs) == 0 {
+		return voteData, nil
+	}
+	directions, err := dao.GetUserPostVoteDirections(userID, archivedIDs)
+	if err != nil { // 遇到错误不返回，投票方向按未投票处理
+		zap.L().Error("查询用户对已归档帖子的投票方向失败", zap.Error(err))
+		return voteData, nil
+	}
+	for i, id := range ids {
+		if voteData[i].Archived {
+			postID, _ := strconv.ParseInt(id, 10, 64)
+			voteData[i].Direction = directions[postID]
+		}
+	}
+	return voteData, nil
+}
+
+// 批量查询当前用户已收藏的帖子，游客或查询失败时返回空 map
+func getBookmarkedPosts(userID int64, postIDs []int64) map[int64]bool {
+	if userID == 0 {
+		return map[int64]bool{}
+	}
+	bookmarked, err := dao.GetBookmarkedPostIDs(userID, postIDs)
+	if err != nil { // 遇到错误不返回，按未收藏处理
+		zap.L().Error("查询用户收藏状态失败", zap.Error(err))
+		return map[int64]bool{}
+	}
+	return bookmarked
+}
+
+// 批量查询帖子的话题标签，查询失败时返回空 map
+func getPostTags(postIDs []int64) map[int64][]string {
+	tags, err := dao.GetPostTagNames(postIDs)
+	if err != nil { // 遇到错误不返回，按无标签处理
+		zap.L().Error("查询帖子标签失败", zap.Error(err))
+		return map[int64][]string{}
+	}
+	return tags
+}
+
+// 格式化帖子或评论的编辑时间，未编辑过返回空字符串
+func formatEditedAt(editedAt *time.Time) string {
+	if editedAt == nil {
+		return ""
+	}
+	return editedAt.Format("2006-01-02 15:04:05")
+}
+
 // 根据id列表查询帖子列表，并封装响应数据
 //func GetPostListByIDs(ids []string, userID int64) (postResponses []*response.PostResponse, err error) {
 //	//调用此函数前，已经对ids进行判断，不为空
@@ -235,16 +577,16 @@ func DeletePost(postID int64, userID int64) error {
 //}
 
 func GetPostListByIDs(ids []string, userID int64) (postResponses []*response.PostResponse, err error) {
-	// 1. 根据id列表去数据库查询帖子详细信息
-	// 注意：SQL 的 WHERE IN (...) 不保证返回顺序，通常按 ID 升序返回
+	// 1. 根据id列表去数据库查询帖子详细信息（按 ids 的顺序返回）
 	posts, err := dao.GetPostListByIDs(ids)
 	if err != nil {
 		return
 	}
 
-	// 2. 查询 Redis 数据（点赞数、评论数）
+	// 2. 查询 Redis 数据（投票数据、评论数）
 	// 这些数据的顺序是严格对应传入的 ids 顺序的
-	voteData, err := redis.GetPostVoteDataByIDs(ids)
+	l := newLoader(userID)
+	voteData, err := l.postVotes(ids)
 	if err != nil {
 		return
 	}
@@ -253,8 +595,28 @@ func GetPostListByIDs(ids []string, userID int64) (postResponses []*response.Pos
 		return
 	}
 
+	// 批量加载作者和社区信息
+	postIDs := make([]int64, len(posts))
+	authorIDs := make([]int64, len(posts))
+	communityIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+		authorIDs[i] = post.AuthorID
+		communityIDs[i] = post.CommunityID
+	}
+	l.loadUsers(authorIDs)
+	l.loadCommunities(communityIDs)
+
+	// 查询当前用户已收藏的帖子
+	bookmarked := getBookmarkedPosts(userID, postIDs)
+
+	// 查询话题标签、提及和附件
+	tags := getPostTags(postIDs)
+	mentions := getPostMentions(postIDs)
+	attachments := getPostAttachments(postIDs)
+
 	// 【关键修复】将 Redis 数据转为 Map，以便通过 ID 精确匹配
-	voteMap := make(map[string]int64)
+	voteMap := make(map[string]redis.VoteData)
 	commentMap := make(map[string]int64)
 	for i, id := range ids {
 		voteMap[id] = voteData[i]
@@ -263,53 +625,33 @@ func GetPostListByIDs(ids []string, userID int64) (postResponses []*response.Pos
 
 	// 3. 遍历数据库返回的帖子列表进行组装
 	for _, post := range posts {
-		// 查询作者简略信息
-		userBriefInfo, err := dao.GetUserBriefInfo(post.AuthorID)
-		if err != nil {
-			zap.L().Error("查询作者信息失败", zap.Error(err))
-			continue
-		}
-
-		// 查询社区详情
-		community, err := dao.GetCommunityById(post.CommunityID)
-		if err != nil {
-			zap.L().Error("查询社区详情失败", zap.Error(err))
-			continue
-		}
-
-		// 查询当前用户是否点赞
-		liked := false
-		if userID != 0 {
-			liked, err = redis.IsUserLikedPost(strconv.Itoa(int(userID)), strconv.Itoa(int(post.ID)))
-			if err != nil {
-				zap.L().Error("查询用户是否点赞失败", zap.Error(err))
-				continue
-			}
-		}
-
 		// ID 转字符串，用于从 Map 取值
 		postIDStr := strconv.FormatInt(post.ID, 10)
+		vote := voteMap[postIDStr]
 
 		postResponse := &response.PostResponse{
 			ID:      post.ID,
 			Content: post.Content,
 			Image:   post.Image,
-			Author:  *userBriefInfo,
+			Author:  l.user(post.AuthorID),
 			// 【关键修复】从 Map 中取值，而不是用 idx，确保数据对应正确
-			LikeCount:    voteMap[postIDStr],
-			CommentCount: commentMap[postIDStr],
-			Liked:        liked,
-			CreatedAt:    post.CreatedAt.Format("2006-01-02 15:04:05"),
-			Community:    response.CommunityBriefResponse{ID: community.ID, CommunityName: community.CommunityName},
+			LikeCount:     vote.Ups,
+			DislikeCount:  vote.Downs,
+			Score:         vote.Score(),
+			VoteDirection: vote.Direction,
+			Bookmarked:    bookmarked[post.ID],
+			CommentCount:  commentMap[postIDStr],
+			CreatedAt:     post.CreatedAt.Format("2006-01-02 15:04:05"),
+			EditedAt:      formatEditedAt(post.EditedAt),
+			RevisionCount: post.RevisionCount,
+			Community:     l.community(post.CommunityID),
+			Tags:          tags[post.ID],
+			Mentions:      mentions[post.ID],
+			Attachments:   attachments[post.ID],
 		}
 
 		postResponses = append(postResponses, postResponse)
 	}
-
-	// 4. (可选) 如果你希望返回结果严格按照时间倒序（因为数据库返回可能是乱序），这里可以再排一次序
-	// 目前前端通常能接受，或者因为 GetPostIDs 已经按时间倒序取了 ID，虽然 posts 乱序，但内容是对的。
-	// 如果需要严格顺序，可以将 postResponses 按照 ids 的顺序重排。
-
 	return
 }
 
@@ -341,19 +683,57 @@ func GetPostList(p *request.ListRequest, userID int64) (postListResponse *respon
 	}
 	// 【修改点】直接去数据库查询 ID 列表和总数
 	// 替代了原有的 redis.GetPostIDsInOrder(p)
-	ids, total, err := dao.GetPostIDs(p)
+	// 启用热度排序算法后，按热度排序时从 redis 的 post:score 中查询
+	// 携带游标时从 redis 的 post:time 中按游标查询
+	var ids []string
+	var total int64
+	if p.Order == constants.OrderScore && ranking.Enabled() {
+		ids, total, postListResponse.NextCursor, err = redis.GetPostIDsInOrder(p)
+	} else if p.Cursor != "" {
+		ids, total, postListResponse.NextCursor, err = redis.GetPostIDsInOrder(timeOrder(p))
+	} else {
+		ids, total, err = dao.GetPostIDs(p)
+		postListResponse.NextCursor = getPageCursor(p, ids, total)
+	}
 	if err != nil {
 		return
 	}
 	postListResponse.Total = total
-	if len(ids) == 0 {
-		return
+	if len(ids) > 0 {
+		// 【保持不变】继续复用原有的 GetPostListByIDs 方法
+		if postListResponse.Posts, err = GetPostListByIDs(ids, userID); err != nil {
+			return
+		}
 	}
-	// 【保持不变】继续复用原有的 GetPostListByIDs 方法
-	postListResponse.Posts, err = GetPostListByIDs(ids, userID)
+	// 全站置顶的帖子放在最前面
+	withPinnedPosts(postListResponse, isFirstPage(p), 0, userID)
 	return
 }
 
+// 未启用热度排序时，按热度排序也按时间倒序查询（与数据库查询保持一致）
+func timeOrder(p *request.ListRequest) *request.ListRequest {
+	timeRequest := *p
+	timeRequest.Order = constants.OrderTime
+	return &timeRequest
+}
+
+// 是否为列表的第一页（携带游标时一定不是第一页）
+func isFirstPage(p *request.ListRequest) bool {
+	return p.Cursor == "" && p.Page <= 1
+}
+
+// 按页码从数据库查询按时间排序的帖子列表时，用本页最后一个帖子在 post:time 中的位置生成下一页的游标
+func getPageCursor(p *request.ListRequest, ids []string, total int64) string {
+	if len(ids) == 0 || p.Page*p.Size >= total {
+		return ""
+	}
+	next, err := redis.GetPostTimeCursor(ids[len(ids)-1])
+	if err != nil { // 遇到错误不返回，只是不提供游标
+		zap.L().Error("生成分页游标失败", zap.Error(err))
+	}
+	return next
+}
+
 // 查询该社区下的帖子列表，并按指定方式排序
 //func GetCommunityPostList(listRequest *request.ListRequest, communityID int64, userID int64) (postListResponse *response.PostListResponse, err error) {
 //	postListResponse = &response.PostListResponse{
@@ -423,18 +803,18 @@ func GetUserPostList(userID int64, listRequest *request.ListRequest) (postListRe
 //	return
 //}
 
-// GetUserLikedPostList 获取用户点赞列表 (修复后：查MySQL)
+// GetUserLikedPostList 获取用户点赞列表（从用户的点赞有序集合中按点赞时间倒序查询）
 func GetUserLikedPostList(userID int64, listRequest *request.ListRequest) (postListResponse *response.PostListResponse, err error) {
 	postListResponse = &response.PostListResponse{
 		Posts: []*response.PostResponse{},
 	}
 
-	// 【修改点】调用 DAO 层查询数据库中的 PostVote 表
-	ids, total, err := dao.GetUserLikedPostIDs(listRequest, userID)
+	ids, total, next, err := redis.GetUserLikeIDsInOrder(userID, listRequest)
 	if err != nil {
 		return
 	}
 	postListResponse.Total = total
+	postListResponse.NextCursor = next
 	if len(ids) == 0 {
 		return
 	}
@@ -450,15 +830,28 @@ func GetCommunityPostList(listRequest *request.ListRequest, communityID int64, u
 		Posts: []*response.PostResponse{},
 	}
 
-	ids, total, err := dao.GetCommunityPostIDs(listRequest, communityID)
+	// 启用热度排序算法后，按热度排序时从 redis 的社区热度集合中查询
+	// 携带游标时从 redis 的社区帖子集合与 post:time 的交集中按游标查询
+	var ids []string
+	var total int64
+	if listRequest.Order == constants.OrderScore && ranking.Enabled() {
+		ids, total, postListResponse.NextCursor, err = redis.GetCommunityHotPostIDs(listRequest, communityID)
+	} else if listRequest.Cursor != "" {
+		ids, total, postListResponse.NextCursor, err = redis.GetCommunityPostIDsInOrder(timeOrder(listRequest), communityID)
+	} else {
+		ids, total, err = dao.GetCommunityPostIDs(listRequest, communityID)
+		postListResponse.NextCursor = getPageCursor(listRequest, ids, total)
+	}
 	if err != nil {
 		return
 	}
 	postListResponse.Total = total
-	if len(ids) == 0 {
-		return
+	if len(ids) > 0 {
+		if postListResponse.Posts, err = GetPostListByIDs(ids, userID); err != nil {
+			return
+		}
 	}
-
-	postListResponse.Posts, err = GetPostListByIDs(ids, userID)
+	// 本社区置顶的帖子放在最前面
+	withPinnedPosts(postListResponse, isFirstPage(listRequest), communityID, userID)
 	return
 }
@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"vision/constants"
+	"vision/dao/redis"
+	"vision/models/request"
+	"vision/models/response"
+	"vision/pkg/hashtag"
+)
+
+// 查询标签下的帖子列表（按时间或热度排序）
+func GetTagPostList(tag string, listRequest *request.ListRequest, userID int64) (postListResponse *response.PostListResponse, err error) {
+	postListResponse = &response.PostListResponse{
+		Posts: []*response.PostResponse{},
+	}
+
+	tag, ok := hashtag.Normalize(tag)
+	if !ok {
+		return nil, constants.ErrorInvalidParam
+	}
+
+	ids, total, next, err := redis.GetTagPostIDsInOrder(listRequest, tag)
+	if err != nil {
+		return
+	}
+	postListResponse.Total = total
+	postListResponse.NextCursor = next
+	if len(ids) == 0 {
+		return
+	}
+
+	postListResponse.Posts, err = GetPostListByIDs(ids, userID)
+	return
+}
+
+// 查询热门标签
+func GetTrendingTags(p *request.TrendingTagsRequest) ([]*response.TagTrendResponse, error) {
+	if p.Hours <= 0 {
+		p.Hours = 24
+	}
+	if p.Hours > redis.TrendingMaxHours {
+		p.Hours = redis.TrendingMaxHours
+	}
+	if p.Size <= 0 {
+		p.Size = 10
+	}
+	if p.Size > 50 {
+		p.Size = 50 // 限制最大值
+	}
+
+	trends, err := redis.GetTrendingTags(p.Hours, p.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	tagTrendResponses := make([]*response.TagTrendResponse, len(trends))
+	for i, trend := range trends {
+		tagTrendResponses[i] = &response.TagTrendResponse{
+			Name:  trend.Name,
+			Count: trend.Count,
+		}
+	}
+	return tagTrendResponses, nil
+}
@@ -334,7 +334,15 @@ func updateRefreshToken(userID int64, oldToken, newToken, deviceID string) error
 
 // 获取用户信息
 func GetUserInfo(id int64) (*entity.User, error) {
-	return dao.GetUserInfo(id)
+	user, err := dao.GetUserInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	// 查询发过的帖子数量，只统计已发布的帖子
+	if user.PostNum, err = dao.CountUserPublishedPosts(id); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 // 更新用户信息
@@ -363,7 +371,12 @@ func UpdateUserInfo(p *request.UpdateUserInfoRequest, id int64) error {
 		Avatar:    p.Avatar,
 	}
 
-	return dao.UpdateUserByID(&newUser)
+	if err := dao.UpdateUserByID(&newUser); err != nil {
+		return err
+	}
+	// 用户名和头像变化后，列表中缓存的作者信息需要失效
+	forgetUserBrief(id)
+	return nil
 }
 
 // 查询用户主页
@@ -11,6 +11,7 @@ import (
 	"vision/constants"
 	"vision/dao/redis"
 	"vision/models/request"
+	"vision/service/ranking"
 )
 
 // 给帖子投票
@@ -43,16 +44,21 @@ func VoteForPost(userID int64, p *request.VoteRequest) error {
 	)
 
 	// 1. 校验帖子是否存在
-	_, err := dao.GetPostById(p.PostID)
+	post, err := dao.GetPublishedPostById(p.PostID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return constants.ErrorNoPost
 		}
 		return err
 	}
+	// 投票已归档（发布超过一周）的帖子不再接受投票
+	if post.VotesArchivedAt != nil {
+		return constants.ErrorVoteTimeExpire
+	}
 
 	// 2. 【新增】将投票记录持久化到 MySQL
 	// 这一步是关键，没有这一步，GetUserLikedPostList 就查不到数据
+	// 写入时会在行锁下再次确认帖子未归档，与归档任务并发时返回 ErrorVoteTimeExpire
 	if err := dao.SaveVote(userID, p.PostID, p.Direction); err != nil {
 		zap.L().Error("mysql save vote failed", zap.Error(err))
 		return err
@@ -60,7 +66,12 @@ func VoteForPost(userID int64, p *request.VoteRequest) error {
 
 	// 3. 在 Redis 中投票 (保持原有逻辑，用于计算热度排行等)
 	// 注意：如果你的业务完全迁移到MySQL，这步可以去掉；但通常为了高性能排行，Redis还是需要的。
-	return redis.VoteForPost(strconv.Itoa(int(userID)), strconv.Itoa(int(p.PostID)), float64(p.Direction))
+	if err := redis.VoteForPost(strconv.Itoa(int(userID)), strconv.Itoa(int(p.PostID)), float64(p.Direction)); err != nil {
+		return err
+	}
+
+	// 4. 按当前排序算法重算帖子热度（legacy 算法下由 VoteForPost 累加分数）
+	return ranking.RefreshPost(post)
 }
 
 // 给评论投票
@@ -76,13 +87,13 @@ func CommentVote(userID int64, p *request.VoteRequest) error {
 	if err != nil {
 		return err
 	}
-	if len(comment) == 0 { // 如果未找到此评论
+	if len(comment) == 0 || comment[0].TombstonedAt != nil { // 如果未找到此评论（或评论已删除）
 		return constants.ErrorNoComment
 	}
 
-	parentID := comment[0].ParentID
+	rootID := comment[0].RootID
 	postID := comment[0].PostID
 
 	// 去redis中投票
-	return redis.VoteForComment(strconv.Itoa(int(userID)), strconv.Itoa(int(p.CommentID)), strconv.Itoa(int(postID)), float64(p.Direction), parentID)
+	return redis.VoteForComment(strconv.Itoa(int(userID)), strconv.Itoa(int(p.CommentID)), strconv.Itoa(int(postID)), float64(p.Direction), rootID, comment[0].CreatedAt)
 }
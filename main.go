@@ -13,7 +13,13 @@ import (
 	"vision/dao/postgres"
 	"vision/pkg/jwt"
 	"vision/pkg/snowflake"
+	"vision/service/archive"
+	"vision/service/attachment"
 	"vision/service/kafka"
+	"vision/service/migrate"
+	"vision/service/publish"
+	"vision/service/ranking"
+	"vision/service/reconcile"
 
 	"go.uber.org/zap"
 
@@ -32,8 +38,24 @@ func main() {
 	if err := setupApp(ctx); err != nil {
 		log.Fatal("Failed to setup app:", err)
 	}
-	// 启动 Kafka 消费者
-	//go startKafkaConsumer(ctx)
+	// 启动发帖消息消费者（仅异步发帖模式）
+	if settings.Conf.AsyncPostCreationEnabled() {
+		startKafkaConsumer(ctx)
+	}
+	// 启动发件箱转发任务（按配置发送到 kafka / pulsar / rocketmq / memory）
+	go kafka.StartOutboxRelay(ctx)
+	// 启动帖子热度重算任务
+	go ranking.StartRecomputeJob(ctx)
+	// 启动帖子投票归档任务
+	go archive.StartVoteArchiveJob(ctx)
+	// 启动 redis 一致性校验任务
+	go reconcile.StartReconcileJob(ctx)
+	// 启动过期附件清理任务
+	go attachment.StartCleanupJob(ctx)
+	// 启动定时发布任务
+	go publish.StartScheduler(ctx)
+	// 迁移旧版本的用户点赞集合
+	go migrate.StartUserLikesMigration(ctx)
 	// 启动服务器
 	runServer(ctx)
 }
@@ -68,9 +90,17 @@ func setupApp(ctx context.Context) error {
 		return fmt.Errorf("init redis failed: %w", err)
 	}
 
-	// 初始化 Kafka 生产者（全局实例）
+	// 初始化帖子热度排序算法
+	if err := ranking.Init(settings.Conf.RankingConfig); err != nil {
+		return fmt.Errorf("init ranking failed: %w", err)
+	}
+
+	// 初始化 redis 一致性校验配置
+	reconcile.Init(settings.Conf.ReconcileConfig)
+
+	// 初始化消息生产者（全局实例，按配置选择消息队列后端）
 	if err := kafka.InitProducer(); err != nil {
-		return fmt.Errorf("init kafka producer failed: %w", err)
+		return fmt.Errorf("init message producer failed: %w", err)
 	}
 
 	// 初始化 JWT
@@ -91,21 +121,26 @@ func setupApp(ctx context.Context) error {
 	return nil
 }
 
+// 异步发帖消费者，退出时在 cleanup 中关闭
+var postConsumer *kafka.Consumer
+
 // startKafkaConsumer 启动 Kafka 消费者（带上下文控制）
 func startKafkaConsumer(ctx context.Context) {
 	consumer, err := kafka.NewConsumer(ctx) // 消费者接收上下文
 	if err != nil {
 		zap.L().Fatal("创建 Kafka 消费者失败", zap.Error(err))
 	}
+	if consumer == nil { // 未启用消息队列
+		return
+	}
 
 	// 注册消息处理函数
 	if err := consumer.Start(kafka.ProcessPostCreation); err != nil {
 		zap.L().Fatal("启动 Kafka 消费者失败", zap.Error(err))
 	}
+	postConsumer = consumer
 
 	zap.L().Info("Kafka 消费者已启动")
-	<-ctx.Done() // 阻塞直到上下文取消
-	zap.L().Info("Kafka 消费者已关闭")
 }
 
 // runServer 启动HTTP服务器并处理优雅关闭
@@ -156,10 +191,15 @@ func runServer(ctx context.Context) {
 
 // cleanup 清理资源
 func cleanup() {
+	// 先停止消费者，等待正在处理的消息写入完成
+	if postConsumer != nil {
+		postConsumer.Close()
+		zap.L().Info("Kafka 消费者已关闭")
+	}
+	kafka.CloseProducer() // 新增：关闭全局生产者
 	//mysql.Close()
 	redis.Close()
 	// 其他需要清理的资源
-	kafka.CloseProducer() // 新增：关闭全局生产者
 }
 
 //func main() {
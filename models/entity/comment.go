@@ -1,14 +1,20 @@
 package entity
 
+import "time"
+
 // 评论
 type Comment struct {
 	BaseModel
 	Content string `gorm:"type:text;not null"`
 
+	EditedAt      *time.Time `gorm:"default:null"`       // 最后一次编辑时间（null表示未编辑过）
+	RevisionCount int64      `gorm:"not null;default:0"` // 历史版本数
+	TombstonedAt  *time.Time `gorm:"default:null"`       // 删除时间：有回复的评论删除后保留为墓碑（清空内容，子评论保留）
+
 	// 评论关联
-	ParentID *int64     `gorm:"index;default:null" json:"parent_id"`                       // 父评论ID（null表示自身是顶级评论）
-	RootID   *int64     `gorm:"ind ex;default:null" json:"root_id"`                        // 根评论ID（null表示自身是顶级评论）
-	Replies  []*Comment `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE;" json:"-"` // 删除评论时，级联删除子评论
+	ParentID *int64     `gorm:"index;default:null" json:"parent_id"` // 父评论ID（null表示自身是顶级评论）
+	RootID   *int64     `gorm:"ind ex;default:null" json:"root_id"`  // 根评论ID（null表示自身是顶级评论）
+	Replies  []*Comment `gorm:"foreignKey:ParentID" json:"-"`        // 子评论（删除评论时保留，父评论成为墓碑）
 
 	// 用户关联
 	AuthorID int64 `gorm:"index;not null"`
@@ -21,3 +27,12 @@ type Comment struct {
 	// 记录点赞用户（多对多）
 	LikedBy []*User `gorm:"many2many:user_likes_comments;"`
 }
+
+// CommentRevision 评论的历史版本，每次编辑前保存一份旧内容
+type CommentRevision struct {
+	BaseModel
+	CommentID int64  `gorm:"not null;uniqueIndex:idx_comment_version" json:"comment_id"`
+	Version   int64  `gorm:"not null;uniqueIndex:idx_comment_version" json:"version"` // 版本号，从1开始
+	Content   string `gorm:"type:text;not null" json:"content"`                       // 该版本的内容
+	EditorID  int64  `gorm:"not null" json:"editor_id"`                               // 执行本次编辑的用户
+}
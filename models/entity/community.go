@@ -9,3 +9,28 @@ type Community struct {
 	// 关联帖子（一对多关系）
 	Posts []Post `gorm:"foreignKey:CommunityID" json:"-"`
 }
+
+// CommunityModerator 社区版主，可以管理本社区的置顶帖子
+type CommunityModerator struct {
+	BaseModel
+	CommunityID int64 `gorm:"not null;uniqueIndex:idx_community_moderator" json:"community_id"`
+	UserID      int64 `gorm:"not null;uniqueIndex:idx_community_moderator;index" json:"user_id"`
+}
+
+// 管理操作类型
+const (
+	ModerationActionEditComment = "edit_comment" // 修改他人评论
+)
+
+// ModerationLog 管理操作记录，管理员或版主处理他人内容时留档
+type ModerationLog struct {
+	BaseModel
+	ModeratorID  int64  `gorm:"not null;index" json:"moderator_id"`           // 执行操作的管理员或版主
+	CommunityID  int64  `gorm:"not null;index" json:"community_id"`           // 内容所属社区
+	Action       string `gorm:"type:varchar(32);not null" json:"action"`      // 操作类型
+	TargetType   string `gorm:"type:varchar(16);not null" json:"target_type"` // 操作对象类型：post / comment
+	TargetID     int64  `gorm:"not null;index" json:"target_id"`              // 操作对象id
+	TargetUserID int64  `gorm:"not null" json:"target_user_id"`               // 内容作者
+	Reason       string `gorm:"type:varchar(255)" json:"reason"`              // 操作原因（可选）
+	Detail       string `gorm:"type:text" json:"detail"`                      // 操作前的内容
+}
@@ -0,0 +1,13 @@
+package entity
+
+// Mention 帖子或评论内容中的 @提及，每处提及保存一行，位置按字符（Unicode 码点）计算
+type Mention struct {
+	BaseModel
+	PostID    int64  `gorm:"not null;uniqueIndex:idx_mention_span" json:"post_id"`
+	CommentID int64  `gorm:"not null;default:0;uniqueIndex:idx_mention_span;index" json:"comment_id"` // 评论id（0 表示帖子正文中的提及）
+	Start     int    `gorm:"column:span_start;not null;uniqueIndex:idx_mention_span" json:"start"`    // 起始位置（包含 @）
+	End       int    `gorm:"column:span_end;not null" json:"end"`                                     // 结束位置（不包含）
+	UserID    int64  `gorm:"not null;index" json:"user_id"`                                           // 被提及的用户
+	Username  string `gorm:"type:varchar(64);not null" json:"username"`                               // 提及时使用的用户名
+	AuthorID  int64  `gorm:"not null" json:"author_id"`                                               // 内容作者
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// ProcessedMessage 已处理的消息记录，消费者据此跳过重复投递的消息
+type ProcessedMessage struct {
+	MessageID   string    `gorm:"primaryKey;type:varchar(64)" json:"message_id"` // 消息中的唯一 ID
+	Topic       string    `gorm:"type:varchar(255);not null" json:"topic"`       // 消息所属主题
+	ProcessedAt time.Time `gorm:"autoCreateTime" json:"processed_at"`            // 处理完成时间
+}
+
+// OutboxMessage 待发送的消息（事务发件箱）
+// 与业务数据在同一个数据库事务中写入，由转发任务发送到 kafka 后标记为已发送，进程崩溃或重启不会丢失消息
+type OutboxMessage struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Topic     string     `gorm:"type:varchar(255);not null" json:"topic"`     // 目标主题
+	Key       string     `gorm:"type:varchar(255);not null" json:"key"`       // 消息 key
+	Payload   []byte     `gorm:"type:bytea;not null" json:"-"`                // 消息体
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`          // 发送失败次数
+	LastError string     `gorm:"type:text" json:"last_error,omitempty"`       // 最后一次发送失败的原因
+	CreatedAt time.Time  `gorm:"autoCreateTime;index" json:"created_at"`      // 写入时间
+	SentAt    *time.Time `gorm:"index;default:null" json:"sent_at,omitempty"` // 发送时间（null表示未发送）
+
+	// 转发任务领取消息后在租约到期前发送，其他实例不会重复领取；发送时不持有数据库锁
+	LockedUntil *time.Time `gorm:"index;default:null" json:"-"`
+	// 失败次数达到上限后搁置的时间，搁置的消息不再自动重试，不会阻塞后续消息（null表示未搁置）
+	ParkedAt *time.Time `gorm:"index;default:null" json:"parked_at,omitempty"`
+}
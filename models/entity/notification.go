@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// 通知类型
+const (
+	NotificationTypeMention = "mention" // 在帖子或评论中被 @提及
+)
+
+// Notification 用户通知，同一事件对同一用户只通知一次
+type Notification struct {
+	BaseModel
+	UserID    int64      `gorm:"not null;uniqueIndex:idx_notification_event;index" json:"user_id"` // 接收通知的用户
+	Type      string     `gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_event" json:"type"`
+	ActorID   int64      `gorm:"not null" json:"actor_id"`                                                // 触发通知的用户
+	PostID    int64      `gorm:"not null;uniqueIndex:idx_notification_event" json:"post_id"`              // 相关帖子
+	CommentID int64      `gorm:"not null;default:0;uniqueIndex:idx_notification_event" json:"comment_id"` // 相关评论（0 表示帖子）
+	ReadAt    *time.Time `gorm:"default:null" json:"read_at"`                                             // 阅读时间（null表示未读）
+}
@@ -1,6 +1,17 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 帖子的发布状态
+const (
+	PostStatusDraft     = "draft"     // 草稿
+	PostStatusScheduled = "scheduled" // 等待定时发布
+	PostStatusPublished = "published" // 已发布
+)
 
 // 帖子
 type Post struct {
@@ -8,6 +19,21 @@ type Post struct {
 	Content string `gorm:"type:text;not_null" json:"content"`
 	Image   string `gorm:"type:text" json:"image"`
 
+	// 发布状态，草稿和定时发布的帖子只写入数据库，发布时才写入 redis
+	// 发布时 created_at 会更新为实际发布时间，排序、投票期限等都以发布时间为准
+	Status    string     `gorm:"type:varchar(16);not null;default:'published';index" json:"status"`
+	PublishAt *time.Time `gorm:"index;default:null" json:"publish_at"` // 定时发布时间，草稿发布后为实际发布时间（直接发布的帖子为 null）
+
+	// 编辑信息
+	EditedAt      *time.Time `gorm:"default:null" json:"edited_at"`            // 最后一次编辑时间（null表示未编辑过）
+	RevisionCount int64      `gorm:"not null;default:0" json:"revision_count"` // 历史版本数
+
+	// 投票归档信息（发布超过一周后，redis 中的投票记录迁移到 PostVote 表）
+	VotesArchivedAt *time.Time `gorm:"index;default:null" json:"-"` // 归档时间（null表示未归档，仍可投票）
+	LikeCount       int64      `gorm:"not null;default:0" json:"-"` // 归档时的赞成票数
+	DislikeCount    int64      `gorm:"not null;default:0" json:"-"` // 归档时的反对票数
+	FinalScore      float64    `gorm:"not null;default:0" json:"-"` // 归档时冻结的热度分数
+
 	// 用户关联（BelongsTo关系）
 	AuthorID int64 `gorm:"index;not null" json:"author_id"`
 	Author   User  `gorm:"foreignKey:AuthorID" json:"-"` // 实现预加载用户信息
@@ -19,8 +45,7 @@ type Post struct {
 	// 评论关联（HasMany关系，级联删除）
 	Comments []Comment `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE;"` // 定义关联关系
 
-	// 记录收藏用户（多对多）
-	CollectedBy []*User `gorm:"many2many:user_likes_comments;"`
+	// 收藏记录见 PostBookmark
 }
 
 // PostVote 记录用户对帖子的投票状态
@@ -30,3 +55,75 @@ type PostVote struct {
 	PostID    int64 `gorm:"column:post_id;not null;uniqueIndex:idx_user_post"`
 	Direction int8  `gorm:"column:direction;not null"` // 1:赞, -1:踩, 0:取消
 }
+
+// PostRevision 帖子的历史版本，每次编辑前保存一份旧内容
+type PostRevision struct {
+	BaseModel
+	PostID   int64  `gorm:"not null;uniqueIndex:idx_post_version" json:"post_id"`
+	Version  int64  `gorm:"not null;uniqueIndex:idx_post_version" json:"version"` // 版本号，从1开始
+	Content  string `gorm:"type:text;not null" json:"content"`                    // 该版本的内容
+	Image    string `gorm:"type:text" json:"image"`                               // 该版本的图片
+	EditorID int64  `gorm:"not null" json:"editor_id"`                            // 执行本次编辑的用户
+}
+
+// BookmarkFolder 用户的收藏夹
+type BookmarkFolder struct {
+	BaseModel
+	UserID int64  `gorm:"not null;uniqueIndex:idx_user_folder_name" json:"user_id"`
+	Name   string `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_folder_name" json:"name"` // 同一用户的收藏夹不能重名
+}
+
+// PostBookmark 用户收藏的帖子
+type PostBookmark struct {
+	BaseModel
+	UserID   int64  `gorm:"not null;uniqueIndex:idx_user_bookmark" json:"user_id"` // 联合唯一索引，防止重复收藏
+	PostID   int64  `gorm:"not null;uniqueIndex:idx_user_bookmark;index" json:"post_id"`
+	FolderID *int64 `gorm:"index;default:null" json:"folder_id"` // 所属收藏夹（null表示未分类）
+}
+
+// Tag 话题标签，可以跨社区聚合帖子
+type Tag struct {
+	BaseModel
+	Name string `gorm:"type:varchar(128);not null;uniqueIndex" json:"name"` // 规范化后的标签名（小写，不带 #）
+}
+
+// PostTag 帖子与标签的关联
+type PostTag struct {
+	PostID    int64     `gorm:"primaryKey" json:"post_id"`
+	TagID     int64     `gorm:"primaryKey;index" json:"tag_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// 附件的媒体类型
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
+// 通过图片上传接口上传、作为帖子 image 字段使用的附件的顺序，不在帖子的附件列表中展示
+const ImageAttachmentSort = -1
+
+// PostAttachment 帖子的图片/视频附件
+// 上传后先处于待使用状态（PostID 为 null），发帖时绑定到帖子；过期仍未使用的附件会被定时清理
+type PostAttachment struct {
+	BaseModel
+	UploaderID int64      `gorm:"index;not null" json:"uploader_id"`
+	PostID     *int64     `gorm:"index;default:null" json:"post_id"`              // 所属帖子（null表示尚未使用）
+	URL        string     `gorm:"type:text;not null" json:"url"`                  // 访问地址
+	ObjectName string     `gorm:"type:varchar(255);not null" json:"-"`            // OSS 中的对象名，清理时使用
+	MediaType  string     `gorm:"type:varchar(16);not null" json:"media_type"`    // image / video
+	Width      int        `gorm:"not null;default:0" json:"width"`                // 宽度（像素）
+	Height     int        `gorm:"not null;default:0" json:"height"`               // 高度（像素）
+	Size       int64      `gorm:"not null;default:0" json:"size"`                 // 文件大小（字节）
+	Sort       int        `gorm:"not null;default:0" json:"sort"`                 // 在帖子中的顺序，从0开始
+	ExpiresAt  *time.Time `gorm:"index;default:null" json:"expires_at,omitempty"` // 未使用附件的过期时间（绑定帖子后为 null）
+}
+
+// PostPin 帖子置顶记录，CommunityID 为 0 表示全站置顶
+type PostPin struct {
+	BaseModel
+	CommunityID int64      `gorm:"not null;uniqueIndex:idx_pin_scope_post" json:"community_id"`
+	PostID      int64      `gorm:"not null;uniqueIndex:idx_pin_scope_post;index" json:"post_id"`
+	PinnedBy    int64      `gorm:"not null" json:"pinned_by"`                      // 执行置顶的管理员或版主
+	ExpiresAt   *time.Time `gorm:"index;default:null" json:"expires_at,omitempty"` // 置顶到期时间（null表示永久置顶）
+}
@@ -0,0 +1,17 @@
+package request
+
+// 重建 redis 数据
+type RebuildRedisRequest struct {
+	DryRun bool `json:"dry_run" form:"dry_run"` // 只报告差异，不写入
+}
+
+// 执行 redis 一致性校验
+type ReconcileRedisRequest struct {
+	Mode   string `json:"mode" form:"mode" binding:"omitempty,oneof=sample full"` // 校验方式
+	DryRun bool   `json:"dry_run" form:"dry_run"`                                 // 只报告差异，不修复
+}
+
+// 查看死信消息
+type ListDeadLettersRequest struct {
+	Size int `json:"size" form:"size" binding:"omitempty,min=1,max=100"` // 返回条数，默认 20
+}
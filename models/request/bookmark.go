@@ -0,0 +1,17 @@
+package request
+
+// 收藏帖子
+type BookmarkPostRequest struct {
+	FolderID *int64 `json:"folder_id"` // 收藏到指定收藏夹，不传则为未分类
+}
+
+// 查询收藏的帖子列表
+type BookmarkListRequest struct {
+	ListRequest
+	FolderID *int64 `json:"folder_id" form:"folder_id"` // 只查询指定收藏夹，0 表示未分类，不传则查询全部
+}
+
+// 创建收藏夹
+type CreateBookmarkFolderRequest struct {
+	Name string `json:"name" binding:"required,max=64"` // 收藏夹名称
+}
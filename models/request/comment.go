@@ -7,3 +7,17 @@ type CreateCommentRequest struct {
 	ParentID *int64 `json:"parent_id,omitempty"`        // 父评论ID（null表示自身是顶级评论）
 	RootID   *int64 `json:"root_id,omitempty"`          // 根评论ID（null表示自身是顶级评论）
 }
+
+// 编辑评论
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"` // 新内容
+	Reason  string `json:"reason" binding:"max=255"`   // 编辑原因（可选，管理员或版主修改他人评论时记录）
+}
+
+// 查询评论树
+type CommentTreeRequest struct {
+	ListRequest       // 顶级评论的分页和排序方式；指定 parent_id 时只使用 size 和 cursor
+	ParentID    int64 `json:"parent_id" form:"parent_id"` // 只查询该评论的回复（可选，用于加载节点的更多回复）
+	Depth       int   `json:"depth" form:"depth"`         // 每个节点向下展开的回复层数，默认 2，最大 5
+	Preview     int   `json:"preview" form:"preview"`     // 每个节点预览的回复数，默认 3，最大 10
+}
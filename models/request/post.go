@@ -2,9 +2,33 @@ package request
 
 // 发布帖子
 type CreatePostRequest struct {
-	Content     string `json:"content" binding:"required"`      // 内容
-	Image       string `json:"image"`                           // 图片（可选）
-	CommunityID int64  `json:"community_id" binding:"required"` // 归属社区
+	Content     string   `json:"content" binding:"required"`      // 内容
+	Image       string   `json:"image"`                           // 图片（可选）
+	CommunityID int64    `json:"community_id" binding:"required"` // 归属社区
+	Tags        []string `json:"tags"`                            // 话题标签（可选），内容中的 #标签 也会被解析
+	Attachments []int64  `json:"attachments" binding:"max=9"`     // 附件id列表（可选），按展示顺序排列，来自上传接口
+	Draft       bool     `json:"draft"`                           // 保存为草稿，不立即发布
+	PublishAt   string   `json:"publish_at"`                      // 定时发布时间（可选），格式 2006-01-02 15:04:05
+}
+
+// 编辑帖子
+type UpdatePostRequest struct {
+	Content string   `json:"content" binding:"required"` // 新内容
+	Image   string   `json:"image"`                      // 新图片（可选）
+	Tags    []string `json:"tags"`                       // 话题标签（可选），与新内容中的 #标签 一起替换原有标签
+}
+
+// 修改草稿
+type UpdateDraftRequest struct {
+	Content   string   `json:"content" binding:"required"` // 新内容
+	Image     string   `json:"image"`                      // 新图片（可选）
+	Tags      []string `json:"tags"`                       // 话题标签（可选），与新内容中的 #标签 一起替换原有标签
+	PublishAt string   `json:"publish_at"`                 // 定时发布时间（可选），不传则保存为草稿
+}
+
+// 发布草稿
+type PublishDraftRequest struct {
+	PublishAt string `json:"publish_at"` // 定时发布时间（可选），不传或已过去则立即发布
 }
 
 // 分页批量查询
@@ -12,4 +36,24 @@ type ListRequest struct {
 	Page  int64  `json:"page" form:"page"`   //查询第几页的数据
 	Size  int64  `json:"size" form:"size"`   //每页数据条数
 	Order string `json:"order" form:"order"` //排序方式
+	// 分页游标（可选），传入上一页返回的 next_cursor 时忽略 page，从上一页最后一条数据之后继续查询
+	Cursor string `json:"cursor" form:"cursor"`
+}
+
+// 查询热门标签
+type TrendingTagsRequest struct {
+	Hours int   `json:"hours" form:"hours"` // 统计最近多少小时，默认 24，最大 72
+	Size  int64 `json:"size" form:"size"`   // 返回的标签数量，默认 10，最大 50
+}
+
+// 上传帖子附件（multipart 表单，文件字段为 file）
+type UploadAttachmentRequest struct {
+	Width  int `form:"width"`  // 视频宽度（可选，图片会自动识别）
+	Height int `form:"height"` // 视频高度（可选，图片会自动识别）
+}
+
+// 置顶帖子
+type PinPostRequest struct {
+	Scope     string `json:"scope" form:"scope" binding:"omitempty,oneof=community global"` // 置顶范围，默认置顶到帖子所属社区
+	ExpiresAt string `json:"expires_at"`                                                    // 置顶到期时间（可选），不传则永久置顶
 }
@@ -0,0 +1,15 @@
+package response
+
+// 收藏夹
+type BookmarkFolderResponse struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`           // 收藏夹名称
+	BookmarkCount int64  `json:"bookmark_count"` // 收藏的帖子数
+	CreatedAt     string `json:"created_at"`     // 创建时间
+}
+
+// 收藏夹列表
+type BookmarkFolderListResponse struct {
+	Folders            []*BookmarkFolderResponse `json:"folders"`
+	UncategorizedCount int64                     `json:"uncategorized_count"` // 未分类的收藏数
+}
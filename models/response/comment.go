@@ -2,20 +2,42 @@ package response
 
 // 评论
 type CommentResponse struct {
-	ID           int64              `json:"id"`
-	Content      string             `json:"content"`                 // 内容
-	Author       *UserBriefResponse `json:"author"`                  // 作者
-	LikeCount    int64              `json:"like_count"`              // 点赞数
-	Liked        bool               `json:"liked"`                   // 当前用户是否已点赞
-	RepliesCount *int64             `json:"replies_count,omitempty"` // 子评论数（只有一级评论需要），指针类型可以使值为0时依然在json中返回
-	Parent       *UserBriefResponse `json:"parent,omitempty"`        // 父评论的作者信息（只有二级以上评论需要）
-	CreatedAt    string             `json:"created_at"`              // 发布时间
-	RootID       int64              `json:"root_id,omitempty"`       // 根评论id（子评论都需要）
-	ParentID     int64              `json:"parent_id,omitempty"`     // 父评论id（为适配前端，只有二级以上评论需要）
+	ID            int64              `json:"id"`
+	Content       string             `json:"content"`                 // 内容
+	Author        *UserBriefResponse `json:"author"`                  // 作者
+	LikeCount     int64              `json:"like_count"`              // 点赞数
+	DislikeCount  int64              `json:"dislike_count"`           // 点踩数
+	Score         int64              `json:"score"`                   // 净得票数（点赞数 - 点踩数）
+	VoteDirection int8               `json:"vote_direction"`          // 当前用户的投票方向：1 赞成 / 0 未投票 / -1 反对
+	RepliesCount  *int64             `json:"replies_count,omitempty"` // 子评论数（只有一级评论需要），指针类型可以使值为0时依然在json中返回
+	Parent        *UserBriefResponse `json:"parent,omitempty"`        // 父评论的作者信息（只有二级以上评论需要）
+	CreatedAt     string             `json:"created_at"`              // 发布时间
+	EditedAt      string             `json:"edited_at,omitempty"`     // 最后编辑时间（未编辑过则不返回）
+	Deleted       bool               `json:"deleted,omitempty"`       // 是否已删除（有回复的评论删除后作为墓碑保留，不返回内容和作者）
+	Mentions      []*MentionResponse `json:"mentions,omitempty"`      // 内容中的 @提及（按位置排序）
+	RootID        int64              `json:"root_id,omitempty"`       // 根评论id（子评论都需要）
+	ParentID      int64              `json:"parent_id,omitempty"`     // 父评论id（为适配前端，只有二级以上评论需要）
 }
 
 // 分页查询评论响应体
 type CommentListResponse struct {
-	Comments []*CommentResponse `json:"comments"`
-	Total    int64              `json:"total"`
+	Comments   []*CommentResponse `json:"comments"`
+	Total      int64              `json:"total"`
+	NextCursor string             `json:"next_cursor,omitempty"` // 下一页的游标（没有更多数据时不返回）
+}
+
+// 评论树节点
+type CommentTreeNode struct {
+	CommentResponse
+	Replies []*CommentTreeNode `json:"replies"` // 回复预览（按发布时间正序），超过展开层数时为空
+	// 是否还有未返回的回复，为 true 时以 parent_id=本评论id、cursor=next_cursor 继续查询评论树
+	HasMoreReplies bool   `json:"has_more_replies"`
+	NextCursor     string `json:"next_cursor,omitempty"` // 加载更多回复的游标（尚未返回任何回复时为空）
+}
+
+// 评论树响应体
+type CommentTreeResponse struct {
+	Comments   []*CommentTreeNode `json:"comments"`
+	Total      int64              `json:"total"`                 // 顶级评论总数（指定 parent_id 时为该评论的回复数）
+	NextCursor string             `json:"next_cursor,omitempty"` // 下一页的游标（没有更多数据时不返回）
 }
@@ -0,0 +1,9 @@
+package response
+
+// 内容中的一处 @提及，位置按字符（Unicode 码点）计算，客户端据此把 [start, end) 渲染为用户链接
+type MentionResponse struct {
+	UserID   int64  `json:"user_id"`  // 被提及的用户
+	Username string `json:"username"` // 提及时使用的用户名
+	Start    int    `json:"start"`    // 起始位置（包含 @）
+	End      int    `json:"end"`      // 结束位置（不包含）
+}
@@ -0,0 +1,19 @@
+package response
+
+// 通知
+type NotificationResponse struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`                 // 通知类型：mention 被 @提及
+	Actor     UserBriefResponse `json:"actor"`                // 触发通知的用户
+	PostID    int64             `json:"post_id"`              // 相关帖子
+	CommentID int64             `json:"comment_id,omitempty"` // 相关评论（帖子正文中的提及不返回）
+	Read      bool              `json:"read"`                 // 是否已读
+	CreatedAt string            `json:"created_at"`
+}
+
+// 分页查询通知响应体
+type NotificationListResponse struct {
+	Notifications []*NotificationResponse `json:"notifications"`
+	Total         int64                   `json:"total"`
+	Unread        int64                   `json:"unread"` // 未读通知数
+}
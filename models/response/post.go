@@ -1,19 +1,72 @@
 package response
 
+import "vision/pkg/diff"
+
 // 帖子列表
 type PostResponse struct {
-	ID           int64                  `json:"id"`
-	Content      string                 `json:"content"`
-	Image        string                 `json:"image"`
-	Author       UserBriefResponse      `json:"author"`        // 作者
-	LikeCount    int64                  `json:"like_count"`    // 点赞数
-	Liked        bool                   `json:"liked"`         // 当前用户是否已点赞
-	CommentCount int64                  `json:"comment_count"` // 评论数
-	CreatedAt    string                 `json:"created_at"`    // 发布时间
-	Community    CommunityBriefResponse `json:"community"`     // 所属社区信息
+	ID            int64                  `json:"id"`
+	Content       string                 `json:"content"`
+	Image         string                 `json:"image"`
+	Author        UserBriefResponse      `json:"author"`               // 作者
+	LikeCount     int64                  `json:"like_count"`           // 点赞数
+	DislikeCount  int64                  `json:"dislike_count"`        // 点踩数
+	Score         int64                  `json:"score"`                // 净得票数（点赞数 - 点踩数）
+	VoteDirection int8                   `json:"vote_direction"`       // 当前用户的投票方向：1 赞成 / 0 未投票 / -1 反对
+	Bookmarked    bool                   `json:"bookmarked"`           // 当前用户是否已收藏
+	Pinned        bool                   `json:"pinned"`               // 是否在当前列表中置顶
+	CommentCount  int64                  `json:"comment_count"`        // 评论数
+	CreatedAt     string                 `json:"created_at"`           // 发布时间
+	EditedAt      string                 `json:"edited_at,omitempty"`  // 最后编辑时间（未编辑过则不返回）
+	RevisionCount int64                  `json:"revision_count"`       // 历史版本数
+	Community     CommunityBriefResponse `json:"community"`            // 所属社区信息
+	Tags          []string               `json:"tags"`                 // 话题标签
+	Mentions      []*MentionResponse     `json:"mentions,omitempty"`   // 正文中的 @提及（按位置排序）
+	Attachments   []*AttachmentResponse  `json:"attachments"`          // 图片/视频附件（按顺序）
+	Status        string                 `json:"status,omitempty"`     // 发布状态（只有草稿和定时发布的帖子返回）
+	PublishAt     string                 `json:"publish_at,omitempty"` // 定时发布时间
+}
+
+// 异步发帖状态
+type PostCreationStatusResponse struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`           // pending 等待写入 / created 已创建 / failed 创建失败
+	Reason string `json:"reason,omitempty"` // 失败原因
+}
+
+// 帖子附件
+type AttachmentResponse struct {
+	ID        int64  `json:"id"`
+	URL       string `json:"url"`
+	MediaType string `json:"media_type"` // image / video
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
 }
 
 type PostListResponse struct {
-	Posts []*PostResponse `json:"posts"`
-	Total int64           `json:"total"`
+	Posts      []*PostResponse `json:"posts"`
+	Total      int64           `json:"total"`
+	NextCursor string          `json:"next_cursor,omitempty"` // 下一页的游标（没有更多数据时不返回）
+}
+
+// 帖子的单个历史版本
+type PostRevisionResponse struct {
+	Version   int64             `json:"version"`    // 版本号
+	Content   string            `json:"content"`    // 该版本的内容
+	Image     string            `json:"image"`      // 该版本的图片
+	Editor    UserBriefResponse `json:"editor"`     // 编辑者
+	CreatedAt string            `json:"created_at"` // 被替换的时间
+	Diff      []diff.Line       `json:"diff"`       // 与下一个版本的逐行差异
+}
+
+// 帖子历史版本列表（按版本号倒序）
+type PostRevisionListResponse struct {
+	PostID    int64                   `json:"post_id"`
+	Revisions []*PostRevisionResponse `json:"revisions"`
+	Total     int64                   `json:"total"`
+}
+
+// 热门标签
+type TagTrendResponse struct {
+	Name  string `json:"name"`  // 标签名
+	Count int64  `json:"count"` // 统计时间内的使用次数
 }
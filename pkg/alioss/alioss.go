@@ -54,3 +54,18 @@ func UploadFile(file multipart.File, fileName, pathName string) (fileURL string,
 
 	return
 }
+
+// 删除文件
+func DeleteFile(objectName string) error {
+	client, err := InitServer()
+	if err != nil {
+		return err
+	}
+
+	bucket, err := client.Bucket(settings.Conf.AliossConfig.BucketName)
+	if err != nil {
+		return err
+	}
+
+	return bucket.DeleteObject(objectName)
+}
@@ -0,0 +1,42 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 列表分页游标：记录上一页最后一个元素的分数和成员，编码为不透明的字符串返回给客户端
+// 下一页从该位置之后继续查询，翻页期间有新数据插入也不会出现重复或遗漏
+
+var ErrInvalid = errors.New("invalid cursor")
+
+type Cursor struct {
+	Score  float64 // 上一页最后一个元素的分数（时间戳、热度等）
+	Member string  // 上一页最后一个元素的成员（帖子id、评论id），用于区分分数相同的元素
+}
+
+// 编码游标
+func Encode(score float64, member string) string {
+	raw := strconv.FormatFloat(score, 'g', -1, 64) + ":" + member
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// 解码游标，格式不正确或分数不是有限数值时返回 ErrInvalid
+func Decode(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	scoreStr, member, ok := strings.Cut(string(raw), ":")
+	if !ok || member == "" {
+		return nil, ErrInvalid
+	}
+	score, err := strconv.ParseFloat(scoreStr, 64)
+	if err != nil || math.IsNaN(score) || math.IsInf(score, 0) {
+		return nil, ErrInvalid
+	}
+	return &Cursor{Score: score, Member: member}, nil
+}
@@ -0,0 +1,60 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		score  float64
+		member string
+	}{
+		{"秒级时间戳", float64(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).Unix()), "1234567890"},
+		{"微秒时间戳", float64(time.Date(2026, 1, 2, 3, 4, 5, 678901000, time.UTC).UnixMicro()), "42"},
+		{"小数热度", 1234.5678901234, "7"},
+		{"负数分数", -1e9, "8"},
+		{"零分", 0, "9"},
+		{"成员包含冒号", 1, "a:b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(Encode(tt.score, tt.member))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got.Score != tt.score || got.Member != tt.member {
+				t.Errorf("Decode() = %+v, want {Score:%v Member:%v}", got, tt.score, tt.member)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"空字符串", ""},
+		{"不是 base64", "not a cursor!"},
+		{"标准 base64 填充", base64.StdEncoding.EncodeToString([]byte("1:23"))},
+		{"缺少分隔符", encode("12345")},
+		{"缺少成员", encode("12345:")},
+		{"缺少分数", encode(":42")},
+		{"分数不是数字", encode("abc:42")},
+		{"分数为 NaN", encode("NaN:42")},
+		{"分数为无穷大", encode("+Inf:42")},
+		{"篡改后的游标", Encode(1.5, "42") + "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Decode(tt.token); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode(%q) = %+v, %v, want ErrInvalid", tt.token, got, err)
+			}
+		})
+	}
+}
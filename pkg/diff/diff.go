@@ -0,0 +1,65 @@
+package diff
+
+import "strings"
+
+// 按行比较两段文本的差异（基于最长公共子序列）
+
+const (
+	OpEqual  = " " // 两个版本都有的行
+	OpInsert = "+" // 新版本新增的行
+	OpDelete = "-" // 旧版本被删除的行
+)
+
+// 单行差异
+type Line struct {
+	Op   string `json:"op"`   // 操作类型：" " / "+" / "-"
+	Text string `json:"text"` // 行内容
+}
+
+// 比较旧文本和新文本，返回逐行差异
+func Lines(oldText, newText string) []Line {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	// lcs[i][j] 表示 a[i:] 和 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// 根据 lcs 表回溯出差异
+	lines := make([]Line, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Op: OpEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: OpDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: OpInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{Op: OpDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{Op: OpInsert, Text: b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package hashtag
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 从帖子内容中解析话题标签，例如 "#稻瘟病" "#rice-blast"
+
+const (
+	MaxTags   = 10 // 单个帖子最多的标签数
+	MaxLength = 32 // 单个标签的最大长度（字符数）
+)
+
+// 标签只能出现在开头或空白之后，避免把链接中的 # 识别为标签
+var tagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// 只允许字母（包括中文）、数字、下划线和连字符
+var validPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// 规范化标签：去掉开头的 #，转为小写，不合法时返回 false
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxLength || !validPattern.MatchString(tag) {
+		return "", false
+	}
+	return tag, true
+}
+
+// 解析内容中的标签，按出现顺序去重
+func Parse(content string) []string {
+	var tags []string
+	for _, match := range tagPattern.FindAllStringSubmatch(content, -1) {
+		tags = append(tags, match[1])
+	}
+	return Merge(tags)
+}
+
+// 合并多组标签：规范化、去重并截断到 MaxTags，忽略不合法的标签
+func Merge(groups ...[]string) []string {
+	seen := make(map[string]struct{})
+	tags := []string{}
+	for _, group := range groups {
+		for _, tag := range group {
+			tag, ok := Normalize(tag)
+			if !ok {
+				continue
+			}
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			if len(tags) == MaxTags {
+				return tags
+			}
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// 从内容和显式指定的标签中得到帖子的标签列表
+func FromPost(content string, explicit []string) []string {
+	return Merge(explicit, Parse(content))
+}
@@ -0,0 +1,84 @@
+package mention
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// 从帖子和评论内容中解析 @提及，例如 "@张三" "@rice_farmer"
+
+const (
+	MaxMentions = 20 // 单条内容最多解析的提及数
+	MaxLength   = 64 // 用户名的最大长度（字符数），与 users.username 一致
+)
+
+// @ 前面不能是英文字母、数字等邮箱地址中的字符，避免把邮箱地址识别为提及（中文后面可以直接 @）
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@.+-])@([\p{L}\p{N}_-]+)`)
+
+// Span 一处提及在内容中的位置
+type Span struct {
+	Username string // 提及的用户名（不含 @）
+	Start    int    // 起始位置（包含 @），按字符（Unicode 码点）计算
+	End      int    // 结束位置（不包含）
+}
+
+// 按出现顺序解析内容中的提及，同一用户出现多次时每处都返回
+// 返回的用户名可能包含紧跟在用户名后面的文字（例如 "@张三你好" 解析为 "张三你好"），需要经过 Resolve 确定实际的用户名
+func Parse(content string) []Span {
+	var spans []Span
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		if len(spans) == MaxMentions {
+			break
+		}
+		// 用户名后面可以直接接中文等文字，超过最大长度时只保留前 MaxLength 个字符，由 Resolve 匹配实际的用户名
+		username := content[match[2]:match[3]]
+		if runes := []rune(username); len(runes) > MaxLength {
+			username = string(runes[:MaxLength])
+		}
+		start := utf8.RuneCountInString(content[:match[2]-1]) // 包含 @
+		spans = append(spans, Span{
+			Username: username,
+			Start:    start,
+			End:      start + 1 + utf8.RuneCountInString(username),
+		})
+	}
+	return spans
+}
+
+// 提及文本的全部前缀（从长到短），用于批量查询可能被提及的用户名
+func Candidates(spans []Span) []string {
+	seen := make(map[string]struct{})
+	var usernames []string
+	for _, span := range spans {
+		runes := []rune(span.Username)
+		for n := len(runes); n > 0; n-- {
+			username := string(runes[:n])
+			if _, ok := seen[username]; ok {
+				continue
+			}
+			seen[username] = struct{}{}
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// 按已存在的用户名确定每处提及的用户名：取提及文本中最长的、已存在的用户名前缀，并相应缩短结束位置
+// 没有任何前缀是已存在的用户名时丢弃该提及
+func Resolve(spans []Span, exists func(username string) bool) []Span {
+	resolved := make([]Span, 0, len(spans))
+	for _, span := range spans {
+		runes := []rune(span.Username)
+		for n := len(runes); n > 0; n-- {
+			username := string(runes[:n])
+			if !exists(username) {
+				continue
+			}
+			span.Username = username
+			span.End = span.Start + 1 + n
+			resolved = append(resolved, span)
+			break
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,88 @@
+package mention
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Span
+	}{
+		{"英文用户名", "hi @rice_farmer!", []Span{{Username: "rice_farmer", Start: 3, End: 15}}},
+		{"中文用户名", "@张三", []Span{{Username: "张三", Start: 0, End: 3}}},
+		{"中文后面直接 @", "你好@张三", []Span{{Username: "张三", Start: 2, End: 5}}},
+		{"用户名后面紧跟中文", "@张三你好", []Span{{Username: "张三你好", Start: 0, End: 5}}},
+		{"位置按字符计算", "早上好 @李四 和 @bob", []Span{
+			{Username: "李四", Start: 4, End: 7},
+			{Username: "bob", Start: 10, End: 14},
+		}},
+		{"同一用户多次提及", "@bob @bob", []Span{
+			{Username: "bob", Start: 0, End: 4},
+			{Username: "bob", Start: 5, End: 9},
+		}},
+		{"邮箱地址", "联系 a@b.com 或 foo.bar@example.com", nil},
+		{"只有 @", "@ @@", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	// 超过 MaxMentions 的提及被忽略
+	content := strings.Repeat("@bob ", MaxMentions+5)
+	if got := len(Parse(content)); got != MaxMentions {
+		t.Errorf("got %d mentions, want %d", got, MaxMentions)
+	}
+
+	// 超过 MaxLength 的提及文本截断到 MaxLength 个字符
+	long := strings.Repeat("张", MaxLength+10)
+	spans := Parse("@" + long)
+	if len(spans) != 1 {
+		t.Fatalf("got %d mentions, want 1", len(spans))
+	}
+	want := Span{Username: strings.Repeat("张", MaxLength), Start: 0, End: MaxLength + 1}
+	if spans[0] != want {
+		t.Errorf("got %+v, want %+v", spans[0], want)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	spans := []Span{{Username: "张三你"}, {Username: "张三"}, {Username: "ab"}}
+	want := []string{"张三你", "张三", "张", "ab", "a"}
+	if got := Candidates(spans); !reflect.DeepEqual(got, want) {
+		t.Errorf("Candidates() = %q, want %q", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	users := map[string]bool{"张三": true, "张三丰": true, "rice_farmer": true}
+	exists := func(username string) bool { return users[username] }
+
+	tests := []struct {
+		name    string
+		content string
+		want    []Span
+	}{
+		{"完全匹配", "@rice_farmer", []Span{{Username: "rice_farmer", Start: 0, End: 12}}},
+		{"后面紧跟中文时取已存在的用户名", "@张三你好", []Span{{Username: "张三", Start: 0, End: 3}}},
+		{"取最长的已存在用户名", "@张三丰来了", []Span{{Username: "张三丰", Start: 0, End: 4}}},
+		{"不存在的用户被丢弃", "@李四你好 @张三", []Span{{Username: "张三", Start: 6, End: 9}}},
+		{"没有提及", "你好", []Span{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(Parse(tt.content), exists); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
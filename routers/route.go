@@ -9,6 +9,7 @@ import (
 
 	"vision/controller"
 	"vision/middleware"
+	"vision/settings"
 )
 
 func SetupRouter(mode string) *gin.Engine {
@@ -68,6 +69,22 @@ func SetupRouter(mode string) *gin.Engine {
 			{
 				// 查看任意用户的登录历史
 				adminGroup.GET("/users/:id/login-history", controller.GetUserLoginHistoryHandler)
+				// 根据数据库重建 redis 数据（?dry_run=true 只报告差异）
+				adminGroup.POST("/redis/rebuild", controller.RebuildRedisHandler)
+				// 查看最近一次 redis 一致性校验报告
+				adminGroup.GET("/redis/drift", controller.GetRedisDriftHandler)
+				// 立即执行一次 redis 一致性校验（?mode=full 全量，?dry_run=true 只报告差异）
+				adminGroup.POST("/redis/reconcile", controller.ReconcileRedisHandler)
+				// 任命社区版主（版主可以管理本社区的置顶帖子）
+				adminGroup.PUT("/communities/:id/moderators/:user_id", controller.AddCommunityModeratorHandler)
+				// 撤销社区版主
+				adminGroup.DELETE("/communities/:id/moderators/:user_id", controller.RemoveCommunityModeratorHandler)
+				// 查看 kafka 死信消息（?size=20）
+				adminGroup.GET("/kafka/dead-letters", controller.ListDeadLettersHandler)
+				// 把死信消息重新投递到原主题
+				adminGroup.POST("/kafka/dead-letters/:partition/:offset/replay", controller.ReplayDeadLetterHandler)
+				// 查看 kafka 发件箱积压（未发送数量、最早未发送消息的等待时间）
+				adminGroup.GET("/kafka/outbox", controller.GetOutboxStatsHandler)
 			}
 		}
 	}
@@ -103,10 +120,18 @@ func SetupRouter(mode string) *gin.Engine {
 		communityPost.GET("/posts/guest", controller.GetPostListHandler)
 		// 查询帖子列表（指定社区）（指定排序方式，默认按时间倒序）（游客登录）
 		communityPost.GET("/community/:id/posts/guest", controller.GetCommunityPostListHandler)
+		// 查询帖子详情（游客登录）
+		communityPost.GET("/post/:id/guest", controller.GetPostDetailHandler)
 		// 查询社区列表
 		communityPost.GET("/community", controller.CommunityHandler)
 		// 查询社区详情
 		communityPost.GET("/community/:id", controller.CommunityDetailHandler)
+		// 查询帖子历史版本
+		communityPost.GET("/post/:id/revisions", controller.GetPostRevisionListHandler)
+		// 查询标签下的帖子列表（指定排序方式，默认按时间倒序）（游客登录）
+		communityPost.GET("/tag/:name/posts/guest", controller.GetTagPostListHandler)
+		// 查询热门标签（?hours=24&size=10）
+		communityPost.GET("/tags/trending", controller.GetTrendingTagsHandler)
 
 		/*需要登录的接口 应该在定义需要认证的路由组时就应用中间件，而不是在定义路由之后*/
 		authCommunityPost := communityPost.Group("/", middleware.JWTAuthMiddleware())
@@ -119,25 +144,55 @@ func SetupRouter(mode string) *gin.Engine {
 			authCommunityPost.GET("/posts", controller.GetPostListHandler)
 			// 查询帖子列表（指定社区）（指定排序方式，默认按时间倒序）（用户登录）
 			authCommunityPost.GET("/community/:id/posts", controller.GetCommunityPostListHandler)
-			// 发布帖子
-			authCommunityPost.POST("/post", controller.CreatePostHandler)
-			// 上传帖子图片
+			// 查询标签下的帖子列表（指定排序方式，默认按时间倒序）（用户登录）
+			authCommunityPost.GET("/tag/:name/posts", controller.GetTagPostListHandler)
+			// 查询帖子详情（用户登录）
+			authCommunityPost.GET("/post/:id", controller.GetPostDetailHandler)
+			// 发布帖子（启用异步发帖时只发送消息，通过 /post/:id/status 查询创建结果）
+			if settings.Conf.AsyncPostCreationEnabled() {
+				authCommunityPost.POST("/post", controller.CreatePostHandlerAsync)
+			} else {
+				authCommunityPost.POST("/post", controller.CreatePostHandler)
+			}
+			// 查询异步发帖状态
+			authCommunityPost.GET("/post/:id/status", controller.GetPostCreationStatusHandler)
+			// 上传帖子图片，返回图片地址，未被帖子使用的图片过期后自动清理
 			authCommunityPost.POST("/upload", controller.UploadPostImageHandler)
+			// 上传帖子附件（图片或短视频），返回附件id供发帖时使用，未被帖子使用的附件过期后自动清理
+			authCommunityPost.POST("/attachment", controller.UploadPostAttachmentHandler)
+			// 编辑帖子
+			authCommunityPost.PUT("/post/:id", controller.UpdatePostHandler)
 			// 删除帖子
 			authCommunityPost.DELETE("/post/:id", controller.DeletePostHandler)
+			// 编辑草稿（可修改定时发布时间）
+			authCommunityPost.PUT("/draft/:id", controller.UpdateDraftHandler)
+			// 发布草稿（指定未来时间则改为定时发布）
+			authCommunityPost.POST("/draft/:id/publish", controller.PublishDraftHandler)
 			// 帖子投票
 			authCommunityPost.POST("/post/vote", controller.PostVoteController)
+			// 收藏帖子（可指定收藏夹，重复收藏会移动到新的收藏夹）
+			authCommunityPost.POST("/post/:id/bookmark", controller.BookmarkPostHandler)
+			// 取消收藏帖子
+			authCommunityPost.DELETE("/post/:id/bookmark", controller.UnbookmarkPostHandler)
+			// 置顶帖子（管理员或社区版主，可指定全站置顶和到期时间）
+			authCommunityPost.POST("/post/:id/pin", controller.PinPostHandler)
+			// 取消置顶帖子
+			authCommunityPost.DELETE("/post/:id/pin", controller.UnpinPostHandler)
 
 			// 发布评论
 			authCommunityPost.POST("/comment", controller.CreateCommentHandler)
 			// 删除评论
 			authCommunityPost.DELETE("/comment/:id", controller.DeleteCommentHandler)
+			// 编辑评论（作者编辑自己的评论，管理员和版主编辑他人评论会记录管理操作）
+			authCommunityPost.PUT("/comment/:id", controller.UpdateCommentHandler)
 			// 查询顶级评论（指定排序方式，默认按时间倒序）
 			authCommunityPost.GET("/first-level-comment/:post_id", controller.GetTopCommentListHandler)
-			// 查询子评论（按时间正序）
+			// 查询子评论（默认按时间正序，order=score 时按投票分数排序）
 			authCommunityPost.GET("/second-level-comment/:comment_id", controller.GetSonCommentListHandler)
 			// 查询帖子所有评论（指定排序方式，默认按时间倒序）
 			authCommunityPost.GET("/comment/:post_id", controller.GetCommentListHandler)
+			// 查询帖子的评论树（分页查询顶级评论，每个节点附带有限层数的回复预览）
+			authCommunityPost.GET("/post/:id/comment-tree", controller.GetCommentTreeHandler)
 			// 评论投票
 			authCommunityPost.POST("/comment/vote", controller.CommentVoteController)
 		}
@@ -146,8 +201,22 @@ func SetupRouter(mode string) *gin.Engine {
 		{
 			// 查询用户的帖子列表（分页）
 			userCommunityPost.GET("/posts", controller.GetUserPostListHandler)
+			// 查询用户的草稿和定时发布的帖子（分页）
+			userCommunityPost.GET("/drafts", controller.GetUserDraftListHandler)
 			// 查询用户点赞的帖子列表（分页）
 			userCommunityPost.GET("/likes", controller.GetUserLikedPostListHandler)
+			// 查询用户收藏的帖子列表（分页，可按收藏夹筛选）
+			userCommunityPost.GET("/bookmarks", controller.GetUserBookmarkedPostListHandler)
+			// 查询用户的收藏夹列表
+			userCommunityPost.GET("/bookmark-folders", controller.GetBookmarkFolderListHandler)
+			// 创建收藏夹
+			userCommunityPost.POST("/bookmark-folders", controller.CreateBookmarkFolderHandler)
+			// 删除收藏夹（其中的收藏移回未分类）
+			userCommunityPost.DELETE("/bookmark-folders/:id", controller.DeleteBookmarkFolderHandler)
+			// 查询用户的通知列表（分页，包含未读数）
+			userCommunityPost.GET("/notifications", controller.GetUserNotificationListHandler)
+			// 把所有通知标记为已读
+			userCommunityPost.PUT("/notifications/read", controller.ReadAllNotificationsHandler)
 		}
 	}
 
@@ -0,0 +1,107 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+)
+
+const (
+	archiveInterval  = time.Hour // 归档任务执行间隔
+	archiveBatchSize = 200       // 每批归档的帖子数量
+)
+
+// StartVoteArchiveJob 启动投票归档任务：定期将发布超过一周的帖子投票从 redis 迁移到数据库
+func StartVoteArchiveJob(ctx context.Context) {
+	zap.L().Info("帖子投票归档任务已启动", zap.Duration("interval", archiveInterval))
+
+	ticker := time.NewTicker(archiveInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := ArchiveExpiredVotes()
+		if err != nil {
+			zap.L().Error("归档帖子投票失败", zap.Error(err))
+		} else if n > 0 {
+			zap.L().Info("归档帖子投票完成", zap.Int("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			zap.L().Info("帖子投票归档任务已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveExpiredVotes 归档所有超过投票期限的帖子，返回归档的帖子数
+func ArchiveExpiredVotes() (int, error) {
+	before := time.Now().Add(-constants.OneWeekInSeconds * time.Second)
+	total := 0
+	for {
+		posts, err := dao.GetPostsToArchive(before, archiveBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(posts) == 0 {
+			return total, nil
+		}
+
+		for _, post := range posts {
+			if err := archivePost(post); err != nil {
+				// 单个帖子失败时停止本轮，避免反复查询到同一批帖子
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+// 归档单个帖子：先在数据库中标记归档并写入票数（此后帖子不再接受投票），再清理 redis
+func archivePost(post *entity.Post) error {
+	postIDStr := strconv.FormatInt(post.ID, 10)
+
+	records, err := redis.GetPostVoteRecords(postIDStr)
+	if err != nil {
+		return err
+	}
+
+	votes := make([]*entity.PostVote, 0, len(records))
+	for userIDStr, direction := range records {
+		userID, err := strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil {
+			zap.L().Warn("忽略无效的投票用户ID", zap.String("user_id", userIDStr), zap.Int64("post_id", post.ID))
+			continue
+		}
+		votes = append(votes, &entity.PostVote{
+			UserID:    userID,
+			PostID:    post.ID,
+			Direction: int8(direction),
+		})
+	}
+
+	score, err := redis.GetPostScore(postIDStr)
+	if err != nil {
+		return err
+	}
+
+	// 票数以数据库中的投票记录为准，包含读取 redis 快照之后、归档之前完成的投票
+	ups, downs, err := dao.ArchivePostVotes(post.ID, votes, score)
+	if err != nil {
+		if errors.Is(err, constants.ErrorNotAffectData) { // 已被其他实例归档
+			return nil
+		}
+		return err
+	}
+
+	return redis.ArchivePostVotes(postIDStr, ups, downs)
+}
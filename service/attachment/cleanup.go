@@ -0,0 +1,75 @@
+package attachment
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/pkg/alioss"
+)
+
+const (
+	cleanupInterval  = time.Hour // 清理任务执行间隔
+	cleanupBatchSize = 200       // 每批清理的附件数量
+)
+
+// StartCleanupJob 启动附件清理任务：定期删除上传后过期仍未被帖子使用的附件
+func StartCleanupJob(ctx context.Context) {
+	zap.L().Info("过期附件清理任务已启动", zap.Duration("interval", cleanupInterval))
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := CleanupExpiredAttachments()
+		if err != nil {
+			zap.L().Error("清理过期附件失败", zap.Error(err))
+		} else if n > 0 {
+			zap.L().Info("清理过期附件完成", zap.Int("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			zap.L().Info("过期附件清理任务已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CleanupExpiredAttachments 删除所有已过期的未使用附件，返回删除的数量
+func CleanupExpiredAttachments() (int, error) {
+	now := time.Now()
+	total := 0
+	for {
+		attachments, err := dao.GetExpiredAttachments(now, cleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(attachments) == 0 {
+			return total, nil
+		}
+
+		for _, attachment := range attachments {
+			// 先删除 OSS 中的文件，失败时保留记录等待下一轮重试
+			// 已过期的附件不能再被绑定到帖子，所以不会误删正在使用的文件
+			if err := alioss.DeleteFile(attachment.ObjectName); err != nil {
+				// 单个附件失败时跳过该附件，推迟其过期时间到下一轮，避免阻塞其他附件的清理
+				zap.L().Error("删除附件文件失败",
+					zap.Int64("attachment_id", attachment.ID),
+					zap.String("object_name", attachment.ObjectName),
+					zap.Error(err))
+				if err := dao.DelayExpiredAttachment(attachment.ID, now.Add(cleanupInterval)); err != nil {
+					return total, err
+				}
+				continue
+			}
+			if err := dao.DeleteExpiredAttachment(attachment.ID); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"vision/settings"
+)
+
+// 领域事件的发布和订阅接口，具体后端由配置选择（settings.AppConfig.MessageBackend）
+// 业务代码只依赖 Publisher / Subscriber，本地开发和测试可以使用进程内的 memory 后端，不依赖外部服务
+
+// 支持的消息队列后端
+const (
+	BackendKafka    = "kafka"
+	BackendPulsar   = "pulsar"
+	BackendRocketMQ = "rocketmq"
+	BackendMemory   = "memory"
+)
+
+// memory 后端的发帖主题和消费者组
+const (
+	memoryTopicPostCreation = "post-creation"
+	memoryGroupPostCreation = "post-creation-consumer"
+)
+
+// Message 领域事件消息
+type Message struct {
+	Topic   string
+	Key     string            // 分区/排序依据，相同 key 的消息按顺序投递
+	Payload []byte            // 消息体
+	Headers map[string]string // 附加信息（kafka 消息头 / pulsar、rocketmq 消息属性）
+	Time    time.Time
+
+	ID string // 后端分配的消息 ID，只在订阅收到的消息中填充
+}
+
+// Handler 消息处理函数
+// 返回 nil 时确认消息；返回错误时不确认，由后端重新投递
+// kafka 不支持单条消息重新投递，返回错误时结束订阅，未提交的消息在重新订阅后再次投递
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher 发布消息
+type Publisher interface {
+	Publish(ctx context.Context, msgs ...*Message) error
+	Close() error
+}
+
+// Subscriber 订阅消息
+type Subscriber interface {
+	// Subscribe 以 group 的身份消费 topic，阻塞直到 ctx 取消或 handler 返回错误（见 Handler）
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	Close() error
+}
+
+// NewPublisher 按配置创建发布者，未启用消息队列时返回 nil
+func NewPublisher() (Publisher, error) {
+	conf := settings.Conf
+	switch backend := conf.MessageBackend(); backend {
+	case "":
+		return nil, nil
+	case BackendKafka:
+		if conf.KafkaConfig == nil {
+			return nil, fmt.Errorf("broker backend %s is not configured", backend)
+		}
+		return newKafkaPublisher(conf.KafkaConfig), nil
+	case BackendPulsar:
+		if conf.PulsarConfig == nil {
+			return nil, fmt.Errorf("broker backend %s is not configured", backend)
+		}
+		return newPulsarPublisher(conf.PulsarConfig)
+	case BackendRocketMQ:
+		if conf.RocketMQConfig == nil {
+			return nil, fmt.Errorf("broker backend %s is not configured", backend)
+		}
+		return newRocketMQPublisher(conf.RocketMQConfig)
+	case BackendMemory:
+		return memory, nil
+	default:
+		return nil, fmt.Errorf("unknown broker backend: %s", backend)
+	}
+}
+
+// NewSubscriber 按配置创建订阅者，未启用消息队列时返回 nil
+func NewSubscriber() (Subscriber, error) {
+	conf := settings.Conf
+	switch backend := conf.MessageBackend(); backend {
+	case "":
+		return nil, nil
+	case BackendKafka:
+		if conf.KafkaConfig == nil {
+			return nil, fmt.Errorf("broker backend %s is not configured", backend)
+		}
+		return newKafkaSubscriber(conf.KafkaConfig), nil
+	case BackendPulsar:
+		if conf.PulsarConfig == nil {
+			return nil, fmt.Errorf("broker backend %s is not configured", backend)
+		}
+		return newPulsarSubscriber(conf.PulsarConfig)
+	case BackendRocketMQ:
+		if conf.RocketMQConfig == nil {
+			return nil, fmt.Errorf("broker backend %s is not configured", backend)
+		}
+		return newRocketMQSubscriber(conf.RocketMQConfig)
+	case BackendMemory:
+		return memory, nil
+	default:
+		return nil, fmt.Errorf("unknown broker backend: %s", backend)
+	}
+}
+
+// PostCreationTopic 当前后端的发帖主题
+func PostCreationTopic() string {
+	conf := settings.Conf
+	switch conf.MessageBackend() {
+	case BackendKafka:
+		return conf.KafkaConfig.TopicPostCreation
+	case BackendPulsar:
+		return conf.PulsarConfig.TopicPostCreation
+	case BackendRocketMQ:
+		return conf.RocketMQConfig.TopicPostCreation
+	default:
+		return memoryTopicPostCreation
+	}
+}
+
+// PostCreationGroup 当前后端的发帖消费者组（pulsar 为订阅名称）
+func PostCreationGroup() string {
+	conf := settings.Conf
+	switch conf.MessageBackend() {
+	case BackendKafka:
+		return conf.KafkaConfig.GroupPostCreation
+	case BackendPulsar:
+		return conf.PulsarConfig.SubscriptionPostCreation
+	case BackendRocketMQ:
+		return conf.RocketMQConfig.GroupPostCreation
+	default:
+		return memoryGroupPostCreation
+	}
+}
@@ -0,0 +1,113 @@
+package broker
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"vision/settings"
+)
+
+// kafka 后端
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// writer 不绑定主题，由每条消息指定，同一个发布者可以发往不同主题
+func newKafkaPublisher(conf *settings.KafkaConfig) *kafkaPublisher {
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(conf.Brokers...),
+			Balancer:     &kafka.LeastBytes{},
+			MaxAttempts:  conf.RetryMax,
+			WriteTimeout: conf.WriteTimeout,
+			ReadTimeout:  conf.ReadTimeout,
+			RequiredAcks: kafka.RequireOne,
+			Compression:  kafka.Zstd,
+			// 死信主题平时很少写入，允许首次写入时自动创建
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, msgs ...*Message) error {
+	kafkaMsgs := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		headers := make([]kafka.Header, 0, len(msg.Headers))
+		for key, value := range msg.Headers {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+		kafkaMsgs[i] = kafka.Message{
+			Topic:   msg.Topic,
+			Key:     []byte(msg.Key),
+			Value:   msg.Payload,
+			Headers: headers,
+			Time:    msg.Time,
+		}
+	}
+	return p.writer.WriteMessages(ctx, kafkaMsgs...)
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+type kafkaSubscriber struct {
+	conf *settings.KafkaConfig
+}
+
+func newKafkaSubscriber(conf *settings.KafkaConfig) *kafkaSubscriber {
+	return &kafkaSubscriber{conf: conf}
+}
+
+// 处理成功后才手动提交 offset
+func (s *kafkaSubscriber) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     s.conf.Brokers,
+		Topic:       topic,
+		GroupID:     group,
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil { // 关闭时读取被取消，直接退出
+				return nil
+			}
+			zap.L().Error("读取 Kafka 消息失败", zap.Error(err))
+			zap.L().Debug("Kafka 配置", zap.Any("reader.Config()", reader.Config()))
+			time.Sleep(1 * time.Second)
+			continue
+		}
+
+		headers := make(map[string]string, len(msg.Headers))
+		for _, header := range msg.Headers {
+			headers[header.Key] = string(header.Value)
+		}
+		if err := handler(ctx, &Message{
+			Topic:   msg.Topic,
+			Key:     string(msg.Key),
+			Payload: msg.Value,
+			Headers: headers,
+			Time:    msg.Time,
+			ID:      strconv.Itoa(msg.Partition) + ":" + strconv.FormatInt(msg.Offset, 10),
+		}); err != nil {
+			return err
+		}
+
+		// 提交失败时消息可能被重新投递，由处理函数去重
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			zap.L().Error("提交 Kafka offset 失败",
+				zap.Int("partition", msg.Partition), zap.Int64("offset", msg.Offset), zap.Error(err))
+		}
+	}
+}
+
+func (s *kafkaSubscriber) Close() error {
+	return nil
+}
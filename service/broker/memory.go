@@ -0,0 +1,108 @@
+package broker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memory 后端：进程内通道，同一进程中的发布者和订阅者共享，用于本地开发和测试
+// 消息不持久化，同一主题的所有订阅者共同消费（不区分消费者组）
+
+const (
+	memoryTopicBuffer   = 1024 // 每个主题缓冲的消息数，缓冲满时发布会阻塞
+	memoryMaxDeliveries = 5    // 单条消息的最大投递次数，处理一直失败时丢弃
+)
+
+// 处理失败后重新投递的间隔，按投递次数递增
+var memoryRedeliveryDelay = 100 * time.Millisecond
+
+var memory = newMemoryBroker()
+
+type memoryBroker struct {
+	mu     sync.Mutex
+	topics map[string]chan *memoryDelivery
+	seq    atomic.Int64
+}
+
+// 一次投递，记录消息已投递的次数
+type memoryDelivery struct {
+	msg      *Message
+	attempts int
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{topics: make(map[string]chan *memoryDelivery)}
+}
+
+func (b *memoryBroker) topic(name string) chan *memoryDelivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch, ok := b.topics[name]
+	if !ok {
+		ch = make(chan *memoryDelivery, memoryTopicBuffer)
+		b.topics[name] = ch
+	}
+	return ch
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, msgs ...*Message) error {
+	for _, msg := range msgs {
+		delivered := *msg
+		delivered.ID = strconv.FormatInt(b.seq.Add(1), 10)
+		select {
+		case b.topic(msg.Topic) <- &memoryDelivery{msg: &delivered}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// 处理失败的消息延迟一段时间后放回主题重新投递，达到最大投递次数后丢弃
+// 订阅取消后尚未放回的消息随之丢弃（memory 后端不持久化消息）
+func (b *memoryBroker) Subscribe(ctx context.Context, topic, _ string, handler Handler) error {
+	ch := b.topic(topic)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d := <-ch:
+			d.attempts++
+			err := handler(ctx, d.msg)
+			if err == nil {
+				continue
+			}
+			if d.attempts >= memoryMaxDeliveries {
+				zap.L().Error("消息多次处理失败，已丢弃",
+					zap.String("topic", topic), zap.String("id", d.msg.ID), zap.Int("attempts", d.attempts), zap.Error(err))
+				continue
+			}
+			go redeliver(ctx, ch, d)
+		}
+	}
+}
+
+// 延迟后把消息放回主题，订阅取消时放弃
+func redeliver(ctx context.Context, ch chan<- *memoryDelivery, d *memoryDelivery) {
+	timer := time.NewTimer(time.Duration(d.attempts) * memoryRedeliveryDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+	select {
+	case ch <- d:
+	case <-ctx.Done():
+	}
+}
+
+// 进程内共享，不需要关闭
+func (b *memoryBroker) Close() error {
+	return nil
+}
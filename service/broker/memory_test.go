@@ -0,0 +1,135 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"vision/settings"
+)
+
+// 使用 memory 后端时，发布的消息按顺序投递给订阅者
+func TestMemoryPublishSubscribe(t *testing.T) {
+	settings.Conf = &settings.AppConfig{BrokerConfig: &settings.BrokerConfig{Backend: BackendMemory}}
+	publisher, err := NewPublisher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscriber, err := NewSubscriber()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan *Message, 3)
+	done := make(chan error, 1)
+	go func() {
+		done <- subscriber.Subscribe(ctx, PostCreationTopic(), PostCreationGroup(), func(_ context.Context, msg *Message) error {
+			received <- msg
+			return nil
+		})
+	}()
+
+	keys := []string{"a", "b", "c"}
+	for _, key := range keys {
+		if err := publisher.Publish(ctx, &Message{Topic: PostCreationTopic(), Key: key, Payload: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, key := range keys {
+		select {
+		case msg := <-received:
+			if msg.Key != key || string(msg.Payload) != key {
+				t.Fatalf("got message %q, want %q", msg.Key, key)
+			}
+			if msg.ID == "" {
+				t.Fatalf("message %q has no ID", msg.Key)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("message %q not delivered", key)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Subscribe returned %v after cancel", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return after cancel")
+	}
+}
+
+// 处理一直失败的消息最多投递 memoryMaxDeliveries 次
+func TestMemoryRedeliveryIsBounded(t *testing.T) {
+	memoryRedeliveryDelay = time.Millisecond
+	b := newMemoryBroker()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var deliveries atomic.Int32
+	go func() {
+		_ = b.Subscribe(ctx, "topic", "group", func(context.Context, *Message) error {
+			deliveries.Add(1)
+			return errors.New("handler failed")
+		})
+	}()
+	if err := b.Publish(ctx, &Message{Topic: "topic", Key: "k"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for deliveries.Load() < memoryMaxDeliveries && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 达到上限后不再投递
+	time.Sleep(50 * time.Millisecond)
+	if got := deliveries.Load(); got != memoryMaxDeliveries {
+		t.Fatalf("got %d deliveries, want %d", got, memoryMaxDeliveries)
+	}
+}
+
+// 订阅取消后，等待重新投递的消息不会留下阻塞的 goroutine
+func TestMemoryRedeliveryStopsOnCancel(t *testing.T) {
+	memoryRedeliveryDelay = time.Hour
+	b := newMemoryBroker()
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = b.Subscribe(ctx, "topic", "group", func(context.Context, *Message) error {
+			failed <- struct{}{}
+			return errors.New("handler failed")
+		})
+	}()
+	if err := b.Publish(ctx, &Message{Topic: "topic", Key: "k"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatal("message not delivered")
+	}
+
+	cancel()
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Fatalf("got %d goroutines after cancel, want at most %d", got, before)
+	}
+}
@@ -0,0 +1,142 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+
+	"vision/settings"
+)
+
+// pulsar 后端
+type pulsarPublisher struct {
+	client      pulsar.Client
+	mu          sync.Mutex
+	producers   map[string]pulsar.Producer // 按主题懒加载
+	sendTimeout time.Duration
+}
+
+func newPulsarClient(conf *settings.PulsarConfig) (pulsar.Client, error) {
+	return pulsar.NewClient(pulsar.ClientOptions{
+		URL:               conf.ServiceURL,
+		ConnectionTimeout: conf.ConnectionTimeout,
+	})
+}
+
+func newPulsarPublisher(conf *settings.PulsarConfig) (*pulsarPublisher, error) {
+	client, err := newPulsarClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &pulsarPublisher{
+		client:      client,
+		producers:   make(map[string]pulsar.Producer),
+		sendTimeout: conf.SendTimeout,
+	}, nil
+}
+
+func (p *pulsarPublisher) producer(topic string) (pulsar.Producer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if producer, ok := p.producers[topic]; ok {
+		return producer, nil
+	}
+	producer, err := p.client.CreateProducer(pulsar.ProducerOptions{
+		Topic:       topic,
+		SendTimeout: p.sendTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.producers[topic] = producer
+	return producer, nil
+}
+
+func (p *pulsarPublisher) Publish(ctx context.Context, msgs ...*Message) error {
+	for _, msg := range msgs {
+		producer, err := p.producer(msg.Topic)
+		if err != nil {
+			return err
+		}
+		if _, err := producer.Send(ctx, &pulsar.ProducerMessage{
+			Key:        msg.Key,
+			Payload:    msg.Payload,
+			Properties: msg.Headers,
+			EventTime:  msg.Time,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *pulsarPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, producer := range p.producers {
+		producer.Close()
+	}
+	p.client.Close()
+	return nil
+}
+
+type pulsarSubscriber struct {
+	client pulsar.Client
+}
+
+func newPulsarSubscriber(conf *settings.PulsarConfig) (*pulsarSubscriber, error) {
+	client, err := newPulsarClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &pulsarSubscriber{client: client}, nil
+}
+
+// 使用 KeyShared 订阅：多个实例共同消费，相同 key 的消息由同一个实例按顺序处理
+// 处理失败的消息 Nack 后由 pulsar 重新投递
+func (s *pulsarSubscriber) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	consumer, err := s.client.Subscribe(pulsar.ConsumerOptions{
+		Topic:            topic,
+		SubscriptionName: group,
+		Type:             pulsar.KeyShared,
+	})
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	for {
+		msg, err := consumer.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if err := handler(ctx, &Message{
+			Topic:   msg.Topic(),
+			Key:     msg.Key(),
+			Payload: msg.Payload(),
+			Headers: msg.Properties(),
+			Time:    msg.EventTime(),
+			ID:      msg.ID().String(),
+		}); err != nil {
+			consumer.Nack(msg)
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+		if err := consumer.Ack(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *pulsarSubscriber) Close() error {
+	s.client.Close()
+	return nil
+}
@@ -0,0 +1,104 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/apache/rocketmq-client-go/v2"
+	"github.com/apache/rocketmq-client-go/v2/consumer"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/apache/rocketmq-client-go/v2/producer"
+
+	"vision/settings"
+)
+
+// rocketmq 后端
+type rocketMQPublisher struct {
+	producer rocketmq.Producer
+}
+
+func newRocketMQPublisher(conf *settings.RocketMQConfig) (*rocketMQPublisher, error) {
+	opts := []producer.Option{
+		producer.WithNameServer(conf.NameServerAddrs),
+		producer.WithRetry(conf.SendRetryTimes),
+	}
+	if conf.SendTimeout > 0 {
+		opts = append(opts, producer.WithSendMsgTimeout(conf.SendTimeout))
+	}
+	p, err := rocketmq.NewProducer(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+	return &rocketMQPublisher{producer: p}, nil
+}
+
+// 以 key 作为分片键，相同 key 的消息发往同一个队列
+func (p *rocketMQPublisher) Publish(ctx context.Context, msgs ...*Message) error {
+	for _, msg := range msgs {
+		rmqMsg := primitive.NewMessage(msg.Topic, msg.Payload).
+			WithKeys([]string{msg.Key}).
+			WithShardingKey(msg.Key)
+		rmqMsg.WithProperties(msg.Headers)
+		if _, err := p.producer.SendSync(ctx, rmqMsg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rocketMQPublisher) Close() error {
+	return p.producer.Shutdown()
+}
+
+type rocketMQSubscriber struct {
+	conf *settings.RocketMQConfig
+}
+
+func newRocketMQSubscriber(conf *settings.RocketMQConfig) (*rocketMQSubscriber, error) {
+	return &rocketMQSubscriber{conf: conf}, nil
+}
+
+// 使用推模式消费，处理失败的消息返回 ConsumeRetryLater 由 rocketmq 重新投递
+func (s *rocketMQSubscriber) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	opts := []consumer.Option{
+		consumer.WithGroupName(group),
+		consumer.WithNameServer(s.conf.NameServerAddrs),
+	}
+	if s.conf.ConsumeTimeout > 0 {
+		opts = append(opts, consumer.WithConsumeTimeout(s.conf.ConsumeTimeout))
+	}
+	c, err := rocketmq.NewPushConsumer(opts...)
+	if err != nil {
+		return err
+	}
+
+	err = c.Subscribe(topic, consumer.MessageSelector{}, func(_ context.Context, msgs ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
+		for _, msg := range msgs {
+			if err := handler(ctx, &Message{
+				Topic:   msg.Topic,
+				Key:     msg.GetKeys(),
+				Payload: msg.Body,
+				Headers: msg.GetProperties(),
+				ID:      msg.MsgId,
+			}); err != nil {
+				return consumer.ConsumeRetryLater, err
+			}
+		}
+		return consumer.ConsumeSuccess, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	return c.Shutdown()
+}
+
+func (s *rocketMQSubscriber) Close() error {
+	return nil
+}
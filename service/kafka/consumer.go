@@ -5,114 +5,178 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+	"vision/constants"
 	"vision/dao"
 	"vision/dao/redis"
 	"vision/models/entity"
 	"vision/models/proto"
-	"vision/settings"
+	"vision/pkg/hashtag"
+	"vision/service/broker"
+	"vision/service/mention"
+	"vision/service/ranking"
 
-	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 	protobuf "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// Consumer Kafka 消费者结构体（带上下文）
+// Consumer 发帖消息消费者（带上下文），按配置使用 kafka / pulsar / rocketmq / memory 后端
 type Consumer struct {
-	ctx    context.Context
-	reader *kafka.Reader
+	ctx        context.Context
+	cancel     context.CancelFunc
+	subscriber broker.Subscriber
+	done       chan struct{} // 消费循环退出后关闭
 }
 
-// NewConsumer 创建消费者实例（接收上下文）
+// NewConsumer 创建消费者实例（接收上下文），未启用消息队列时返回 nil
 func NewConsumer(ctx context.Context) (*Consumer, error) {
-	conf := settings.Conf.KafkaConfig
-	if !conf.Enabled {
-		return nil, nil
+	subscriber, err := broker.NewSubscriber()
+	if err != nil || subscriber == nil {
+		return nil, err
+	}
+	// 死信消息通过全局生产者发送
+	if producer == nil {
+		return nil, constants.ErrKafkaNotEnabled
 	}
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     conf.Brokers,
-		Topic:       conf.TopicPostCreation,
-		GroupID:     conf.GroupPostCreation,
-		StartOffset: kafka.FirstOffset,
-	})
-
+	ctx, cancel := context.WithCancel(ctx)
 	return &Consumer{
-		ctx:    ctx,
-		reader: reader,
+		ctx:        ctx,
+		cancel:     cancel,
+		subscriber: subscriber,
+		done:       make(chan struct{}),
 	}, nil
 }
 
+// Close 停止消费并等待正在处理的消息完成
+func (c *Consumer) Close() {
+	if c == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+	if err := c.subscriber.Close(); err != nil {
+		zap.L().Error("关闭消息订阅失败", zap.Error(err))
+	}
+}
+
+// 订阅发帖主题直到消费者关闭，订阅异常退出时稍后重新订阅
+func (c *Consumer) subscribe(handler broker.Handler) {
+	for {
+		err := c.subscriber.Subscribe(c.ctx, broker.PostCreationTopic(), broker.PostCreationGroup(), handler)
+		if c.ctx.Err() != nil {
+			return
+		}
+		zap.L().Error("消息订阅异常退出，稍后重新订阅", zap.Error(err))
+		if !c.sleep(time.Second) {
+			return
+		}
+	}
+}
+
 // Start 启动消费者循环（接收消息处理函数）
 func (c *Consumer) StartUseJson(handler func(PostCreationMessage) error) error {
 	go func() {
-		defer c.reader.Close()
-
-		for {
-			select {
-			case <-c.ctx.Done(): // 监听上下文取消
-				return
-			default:
-				msg, err := c.reader.ReadMessage(c.ctx)
-				if err != nil {
-					zap.L().Error("读取 Kafka 消息失败", zap.Error(err))
-					time.Sleep(1 * time.Second)
-					continue
-				}
-
-				var message PostCreationMessage
-				if err := json.Unmarshal(msg.Value, &message); err != nil {
-					zap.L().Error("解析 Kafka 消息失败", zap.Error(err))
-					continue
-				}
-
-				// 处理消息（调用外部传入的处理函数）
-				if err := handler(message); err != nil {
-					zap.L().Error("处理 Kafka 消息失败", zap.Error(err))
-				}
+		defer close(c.done)
+
+		c.subscribe(func(_ context.Context, msg *broker.Message) error {
+			var message PostCreationMessage
+			if err := json.Unmarshal(msg.Payload, &message); err != nil {
+				zap.L().Error("解析 Kafka 消息失败", zap.Error(err))
+				return nil
 			}
-		}
+
+			// 处理消息（调用外部传入的处理函数）
+			if err := handler(message); err != nil {
+				zap.L().Error("处理 Kafka 消息失败", zap.Error(err))
+			}
+			return nil
+		})
 	}()
 
 	return nil
 }
 
 // Start 启动消费者循环（接收消息处理函数）
+// 消息处理成功后才确认；处理失败时按退避间隔重试，达到最大次数后写入死信主题再确认
+// 重复投递由处理函数按消息 ID 去重
 func (c *Consumer) Start(handler func(*proto.PostCreationMessage) error) error {
 	go func() {
-		defer c.reader.Close()
-
-		for {
-			select {
-			case <-c.ctx.Done(): // 监听上下文取消
-				return
-			default:
-				msg, err := c.reader.ReadMessage(c.ctx)
-				if err != nil {
-					zap.L().Error("读取 Kafka 消息失败", zap.Error(err))
-					zap.L().Debug("Kafka 配置", zap.Any("c.reader.Config()", c.reader.Config()))
-					time.Sleep(1 * time.Second)
-					continue
-				}
-
-				var message proto.PostCreationMessage
-				// 使用 proto 包的 Unmarshal 函数
-				if err := protobuf.Unmarshal(msg.Value, &message); err != nil {
-					zap.L().Error("解析 Kafka 消息失败", zap.Error(err))
-					continue
-				}
-
-				// 处理消息（调用外部传入的处理函数）
-				if err := handler(&message); err != nil {
-					zap.L().Error("处理 Kafka 消息失败", zap.Error(err))
-				}
+		defer close(c.done)
+
+		c.subscribe(func(_ context.Context, msg *broker.Message) error {
+			var message proto.PostCreationMessage
+			// 使用 proto 包的 Unmarshal 函数
+			if err := protobuf.Unmarshal(msg.Payload, &message); err != nil {
+				// 无法解析的消息重试也不会成功，直接写入死信主题
+				zap.L().Error("解析 Kafka 消息失败", zap.Error(err))
+				return c.deadLetter(msg, fmt.Errorf("解析消息失败: %w", err), 1)
 			}
-		}
+
+			// 处理消息（调用外部传入的处理函数）
+			return c.process(msg, &message, handler)
+		})
 	}()
 
 	return nil
 }
 
+// 处理单条消息，失败时按退避间隔重试，最终仍失败则写入死信主题
+// 返回 nil 表示可以确认消息，消费者关闭时返回错误，消息不确认
+func (c *Consumer) process(msg *broker.Message, message *proto.PostCreationMessage, handler func(*proto.PostCreationMessage) error) error {
+	backoff := retryBackoff()
+	attempts := maxAttempts()
+	for attempt := 1; ; attempt++ {
+		err := handler(message)
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			zap.L().Error("处理 Kafka 消息失败，写入死信主题",
+				zap.String("message_id", message.MessageId), zap.Int("attempts", attempt), zap.Error(err))
+			if err := c.deadLetter(msg, err, attempt); err != nil {
+				return err
+			}
+			setCreationStatus(message, constants.PostCreationFailed, "处理失败")
+			return nil
+		}
+		zap.L().Warn("处理 Kafka 消息失败，稍后重试",
+			zap.String("message_id", message.MessageId), zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff), zap.Error(err))
+		if !c.sleep(backoff) { // 关闭时未确认的消息在重启或再均衡后重新投递
+			return c.ctx.Err()
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// 把消息写入死信主题，写入失败时持续重试，不确认原消息以免丢失
+// 消费者关闭时返回错误
+func (c *Consumer) deadLetter(msg *broker.Message, cause error, attempts int) error {
+	backoff := retryBackoff()
+	for {
+		err := producer.publisher.Publish(c.ctx, buildDeadLetter(msg, cause, attempts))
+		if err == nil {
+			return nil
+		}
+		zap.L().Error("写入死信主题失败", zap.String("id", msg.ID), zap.Error(err))
+		if !c.sleep(backoff) {
+			return c.ctx.Err()
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// 等待一段时间，消费者关闭时返回 false
+func (c *Consumer) sleep(d time.Duration) bool {
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
 // ProcessPostCreation 处理帖子创建消息（可导出）
 func ProcessPostCreationUseJson(message PostCreationMessage) error {
 	zap.L().Info("收到帖子创建消息",
@@ -132,22 +196,48 @@ func ProcessPostCreationUseJson(message PostCreationMessage) error {
 		return fmt.Errorf("写入数据库失败: %w", err)
 	}
 
+	// 绑定帖子图片对应的附件
+	if err := dao.BindImageAttachment(post.ID, post.AuthorID, post.Image); err != nil {
+		zap.L().Error("绑定帖子图片失败", zap.Error(err))
+	}
+
+	// 保存内容中解析出的话题标签
+	tags := hashtag.Parse(post.Content)
+	if err := dao.SavePostTags(post.ID, tags); err != nil {
+		zap.L().Error("保存帖子标签失败", zap.Error(err))
+	}
+
 	// 保存到 Redis
 	if err := redis.CreatePost(post.ID, post.CommunityID); err != nil {
 		zap.L().Error("保存到 Redis 失败", zap.Error(err))
 	}
+	if err := redis.AddPostTags(post.ID, tags, post.CreatedAt); err != nil {
+		zap.L().Error("保存帖子标签到 Redis 失败", zap.Error(err))
+	}
 
 	return nil
 }
 
 // ProcessPostCreation 处理帖子创建消息（可导出）
+// 以 MessageId 去重：处理完成的消息记录到数据库，重复投递时直接跳过
+// 中途失败的消息重试时，数据库写入按预先分配的帖子 ID 去重，redis 写入可重复执行
 func ProcessPostCreation(message *proto.PostCreationMessage) error {
 	zap.L().Info("收到帖子创建消息",
 		zap.String("message_id", message.MessageId),
 		zap.Int64("user_id", message.UserId))
 
-	// 构建帖子实体
+	processed, err := dao.IsMessageProcessed(message.MessageId)
+	if err != nil {
+		return fmt.Errorf("查询消息处理记录失败: %w", err)
+	}
+	if processed {
+		zap.L().Info("跳过已处理的帖子创建消息", zap.String("message_id", message.MessageId))
+		return nil
+	}
+
+	// 构建帖子实体，使用发送消息时预先生成的帖子 ID，客户端据此查询创建状态
 	post := &entity.Post{
+		BaseModel:   entity.BaseModel{ID: message.PostId},
 		Content:     message.Content,
 		Image:       message.Image,
 		AuthorID:    message.UserId,
@@ -159,19 +249,61 @@ func ProcessPostCreation(message *proto.PostCreationMessage) error {
 		post.CreatedAt = timestampToTime(message.CreatedAt)
 	}
 
-	// 写入数据库
-	if err := dao.CreatePost(post); err != nil {
+	// 写入数据库（上次处理中途失败时帖子可能已存在）
+	if _, err := dao.CreatePostIfAbsent(post); err != nil {
 		return fmt.Errorf("写入数据库失败: %w", err)
 	}
 
+	// 绑定帖子图片对应的附件（重复处理时附件已绑定，不会重复更新）
+	if err := dao.BindImageAttachment(post.ID, post.AuthorID, post.Image); err != nil {
+		return fmt.Errorf("绑定帖子图片失败: %w", err)
+	}
+
+	// 保存内容中解析出的话题标签
+	tags := hashtag.Parse(post.Content)
+	if err := dao.SavePostTags(post.ID, tags); err != nil {
+		return fmt.Errorf("保存帖子标签失败: %w", err)
+	}
+
+	// 保存内容中的 @提及并通知被提及的用户（重复处理时通知会去重）
+	mentions, err := mention.Save(post.ID, 0, post.AuthorID, post.Content)
+	if err != nil {
+		return fmt.Errorf("保存帖子提及失败: %w", err)
+	}
+	if err := mention.Notify(mentions); err != nil {
+		return fmt.Errorf("发送提及通知失败: %w", err)
+	}
+
 	// 保存到 Redis
 	if err := redis.CreatePost(post.ID, post.CommunityID); err != nil {
-		zap.L().Error("保存到 Redis 失败", zap.Error(err))
+		return fmt.Errorf("保存到 Redis 失败: %w", err)
+	}
+	if err := redis.AddPostTags(post.ID, tags, post.CreatedAt); err != nil {
+		return fmt.Errorf("保存帖子标签到 Redis 失败: %w", err)
 	}
 
+	// 按当前排序算法计算初始热度
+	if err := ranking.RefreshPost(post); err != nil {
+		zap.L().Error("计算帖子热度失败", zap.Error(err))
+	}
+
+	// 数据库和 redis 都写入成功后才记录消息已处理
+	if err := dao.MarkMessageProcessed(message.MessageId, broker.PostCreationTopic()); err != nil {
+		return fmt.Errorf("记录消息处理结果失败: %w", err)
+	}
+
+	setCreationStatus(message, constants.PostCreationCreated, "")
 	return nil
 }
 
+// 更新异步发帖状态，供客户端轮询
+func setCreationStatus(message *proto.PostCreationMessage, status, reason string) {
+	if err := redis.SetPostCreationStatus(message.PostId, message.UserId, status, reason); err != nil {
+		zap.L().Error("更新异步发帖状态失败",
+			zap.Int64("post_id", message.PostId), zap.String("status", status), zap.Error(err))
+	}
+}
+
 // 辅助函数：将 Protobuf 时间戳转换为 time.Time
 func timestampToTime(ts *timestamppb.Timestamp) time.Time {
 	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC()
@@ -0,0 +1,286 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+	"vision/constants"
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+	"vision/models/proto"
+	"vision/service/broker"
+	"vision/settings"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// 死信消息：重试多次仍处理失败（或无法解析）的消息写入死信主题
+// 消息体保持原始内容，失败信息放在消息头中，管理员查看后可重新投递到原主题
+// 帖子创建按 MessageId 去重，重复投递同一条死信不会创建重复帖子
+
+const (
+	defaultMaxAttempts  = 5
+	defaultRetryBackoff = time.Second
+	maxRetryBackoff     = 30 * time.Second
+
+	// 死信消息头
+	headerError       = "x-error"
+	headerAttempts    = "x-attempts"
+	headerOriginTopic = "x-origin-topic"
+	headerOriginID    = "x-origin-id" // 原消息在消息队列中的 ID（kafka 为 分区:offset）
+	headerFailedAt    = "x-failed-at"
+
+	// 查看死信时单个分区的读取超时时间
+	deadLetterReadTimeout = 5 * time.Second
+)
+
+// DeadLetter 死信消息
+type DeadLetter struct {
+	Partition   int    `json:"partition"` // 在死信主题中的分区
+	Offset      int64  `json:"offset"`    // 在死信主题中的 offset，重新投递时使用
+	Error       string `json:"error"`     // 最后一次处理失败的原因
+	Attempts    int    `json:"attempts"`  // 尝试处理的次数
+	OriginTopic string `json:"origin_topic"`
+	OriginID    string `json:"origin_id"` // 原消息在消息队列中的 ID
+	FailedAt    string `json:"failed_at"`
+	Payload     []byte `json:"payload"` // 原始消息体（JSON 中为 base64）
+
+	// 能解析为帖子创建消息时返回的关键信息
+	MessageID string `json:"message_id,omitempty"`
+	PostID    int64  `json:"post_id,omitempty"`
+	UserID    int64  `json:"user_id,omitempty"`
+}
+
+// 死信主题，依次使用 broker.topic_dead_letter、kafka.topic_dead_letter，都未配置时为发帖主题加 .dlq 后缀
+func deadLetterTopic() string {
+	if conf := settings.Conf.BrokerConfig; conf != nil && conf.TopicDeadLetter != "" {
+		return conf.TopicDeadLetter
+	}
+	if conf := settings.Conf.KafkaConfig; conf != nil && conf.TopicDeadLetter != "" {
+		return conf.TopicDeadLetter
+	}
+	return broker.PostCreationTopic() + ".dlq"
+}
+
+// 消费最大尝试次数，broker 中未配置时兼容 kafka.max_attempts
+func maxAttempts() int {
+	if conf := settings.Conf.BrokerConfig; conf != nil && conf.MaxAttempts > 0 {
+		return conf.MaxAttempts
+	}
+	if conf := settings.Conf.KafkaConfig; conf != nil && conf.MaxAttempts > 0 {
+		return conf.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+// 首次重试间隔，broker 中未配置时兼容 kafka.retry_backoff
+func retryBackoff() time.Duration {
+	if conf := settings.Conf.BrokerConfig; conf != nil && conf.RetryBackoff > 0 {
+		return conf.RetryBackoff
+	}
+	if conf := settings.Conf.KafkaConfig; conf != nil && conf.RetryBackoff > 0 {
+		return conf.RetryBackoff
+	}
+	return defaultRetryBackoff
+}
+
+// 下一次重试间隔，每次翻倍，不超过 maxRetryBackoff
+func nextBackoff(d time.Duration) time.Duration {
+	if d *= 2; d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return d
+}
+
+// 构建死信消息：保留原始 key、消息体和消息头，失败信息写入消息头
+func buildDeadLetter(msg *broker.Message, cause error, attempts int) *broker.Message {
+	headers := make(map[string]string, len(msg.Headers)+5)
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[headerError] = cause.Error()
+	headers[headerAttempts] = strconv.Itoa(attempts)
+	headers[headerOriginTopic] = msg.Topic
+	headers[headerOriginID] = msg.ID
+	headers[headerFailedAt] = time.Now().Format("2006-01-02 15:04:05")
+	return &broker.Message{
+		Topic:   deadLetterTopic(),
+		Key:     msg.Key,
+		Payload: msg.Payload,
+		Headers: headers,
+		Time:    time.Now(),
+	}
+}
+
+// 查看和重新投递死信需要直接读取 kafka 分区，只支持 kafka 后端
+
+// 解析死信主题中的消息
+func parseDeadLetter(msg kafka.Message) *DeadLetter {
+	letter := &DeadLetter{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Payload:   msg.Value,
+	}
+	for _, header := range msg.Headers {
+		value := string(header.Value)
+		switch header.Key {
+		case headerError:
+			letter.Error = value
+		case headerAttempts:
+			letter.Attempts, _ = strconv.Atoi(value)
+		case headerOriginTopic:
+			letter.OriginTopic = value
+		case headerOriginID:
+			letter.OriginID = value
+		case headerFailedAt:
+			letter.FailedAt = value
+		}
+	}
+
+	var message proto.PostCreationMessage
+	if err := protobuf.Unmarshal(msg.Value, &message); err == nil {
+		letter.MessageID = message.MessageId
+		letter.PostID = message.PostId
+		letter.UserID = message.UserId
+	}
+	return letter
+}
+
+// ListDeadLetters 查看死信主题中最近的消息，每个分区最多读取 size 条，按失败时间倒序返回 size 条
+func ListDeadLetters(ctx context.Context, size int) ([]*DeadLetter, error) {
+	if settings.Conf.MessageBackend() != broker.BackendKafka || len(settings.Conf.KafkaConfig.Brokers) == 0 {
+		return nil, constants.ErrKafkaNotEnabled
+	}
+	topic := deadLetterTopic()
+
+	conn, err := kafka.DialContext(ctx, "tcp", settings.Conf.KafkaConfig.Brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]*DeadLetter, 0)
+	for _, partition := range partitions {
+		first, last, err := readPartitionOffsets(ctx, topic, partition.ID)
+		if err != nil {
+			return nil, err
+		}
+		start := last - int64(size)
+		if start < first {
+			start = first
+		}
+		msgs, err := readPartition(ctx, topic, partition.ID, start, last)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			letters = append(letters, parseDeadLetter(msg))
+		}
+	}
+
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].FailedAt > letters[j].FailedAt
+	})
+	if len(letters) > size {
+		letters = letters[:size]
+	}
+	return letters, nil
+}
+
+// ReplayDeadLetter 把死信重新投递到原主题（写入发件箱），并把帖子创建状态恢复为等待中
+func ReplayDeadLetter(ctx context.Context, partition int, offset int64) (*DeadLetter, error) {
+	if settings.Conf.MessageBackend() != broker.BackendKafka || len(settings.Conf.KafkaConfig.Brokers) == 0 || producer == nil {
+		return nil, constants.ErrKafkaNotEnabled
+	}
+	topic := deadLetterTopic()
+
+	first, last, err := readPartitionOffsets(ctx, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	if offset < first || offset >= last {
+		return nil, constants.ErrorNoDeadLetter
+	}
+	msgs, err := readPartition(ctx, topic, partition, offset, offset+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, constants.ErrorNoDeadLetter
+	}
+	letter := parseDeadLetter(msgs[0])
+
+	// 通过发件箱写回原主题（保留原始 key，分区分配与首次投递一致）
+	originTopic := letter.OriginTopic
+	if originTopic == "" {
+		originTopic = broker.PostCreationTopic()
+	}
+	if err := dao.WithOutbox(nil, &entity.OutboxMessage{
+		Topic:   originTopic,
+		Key:     string(msgs[0].Key),
+		Payload: msgs[0].Value,
+	}); err != nil {
+		return nil, err
+	}
+	zap.L().Info("死信消息已重新投递",
+		zap.Int("partition", partition), zap.Int64("offset", offset), zap.String("message_id", letter.MessageID))
+
+	if letter.PostID != 0 {
+		if err := redis.SetPostCreationStatus(letter.PostID, letter.UserID, constants.PostCreationPending, ""); err != nil {
+			zap.L().Error("更新异步发帖状态失败", zap.Int64("post_id", letter.PostID), zap.Error(err))
+		}
+	}
+	return letter, nil
+}
+
+// 读取分区的起止 offset，last 为下一条消息的 offset
+func readPartitionOffsets(ctx context.Context, topic string, partition int) (first, last int64, err error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", settings.Conf.KafkaConfig.Brokers[0], topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	return conn.ReadOffsets()
+}
+
+// 读取分区中 [start, end) 范围内的消息
+func readPartition(ctx context.Context, topic string, partition int, start, end int64) ([]kafka.Message, error) {
+	if start >= end {
+		return nil, nil
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   settings.Conf.KafkaConfig.Brokers,
+		Topic:     topic,
+		Partition: partition,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(start); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deadLetterReadTimeout)
+	defer cancel()
+	msgs := make([]kafka.Message, 0, end-start)
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Offset >= end {
+			break
+		}
+		msgs = append(msgs, msg)
+		if msg.Offset == end-1 {
+			break
+		}
+	}
+	return msgs, nil
+}
@@ -0,0 +1,227 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+	"vision/constants"
+	"vision/dao"
+	"vision/models/entity"
+	"vision/models/proto"
+	"vision/service/broker"
+	"vision/settings"
+
+	"go.uber.org/zap"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// 事务发件箱：消息先写入数据库 outbox_messages 表，由转发任务按顺序发送到消息队列
+// 转发任务先领取一批消息（设置租约），再在数据库事务之外发送，发送期间不持有行锁
+// 发送失败的消息保留在表中，下一轮继续发送，失败次数达到上限后搁置，不阻塞后续消息；已发送的消息保留一段时间后清理
+
+const (
+	defaultOutboxInterval    = time.Second
+	defaultOutboxBatchSize   = 100
+	defaultOutboxMaxAttempts = 10
+	outboxLease              = 30 * time.Second   // 领取消息的租约时长，进程在发送期间崩溃时租约到期后由其他实例重新领取
+	outboxRetention          = 7 * 24 * time.Hour // 已发送消息的保留时间
+	outboxCleanupInterval    = time.Hour
+)
+
+// OutboxStats 发件箱转发指标
+type OutboxStats struct {
+	Pending         int64   `json:"pending"`                     // 未发送的消息数（转发积压，不含已搁置的消息）
+	Parked          int64   `json:"parked"`                      // 失败次数达到上限、已搁置的消息数
+	OldestPendingAt string  `json:"oldest_pending_at,omitempty"` // 最早一条未发送消息的写入时间
+	OldestWaitSecs  float64 `json:"oldest_wait_seconds"`         // 最早一条未发送消息已等待的秒数
+	SentTotal       int64   `json:"sent_total"`                  // 本进程启动以来发送成功的消息数
+	FailedTotal     int64   `json:"failed_total"`                // 本进程启动以来发送失败的消息数
+	LastRelayAt     string  `json:"last_relay_at,omitempty"`     // 最近一次转发时间
+	LastError       string  `json:"last_error,omitempty"`        // 最近一次发送失败的原因
+}
+
+var (
+	statsMu     sync.Mutex
+	sentTotal   int64
+	failedTotal int64
+	lastRelayAt time.Time
+	lastError   string
+)
+
+// SendPostCreationMessage 把帖子创建消息写入发件箱，由转发任务发送到消息队列
+// 异步发帖在接口中没有其他数据库写入，消息本身即为需要持久化的业务数据
+func SendPostCreationMessage(message *proto.PostCreationMessage) error {
+	if producer == nil {
+		return constants.ErrKafkaNotEnabled
+	}
+
+	payload, err := protobuf.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return dao.WithOutbox(nil, &entity.OutboxMessage{
+		Topic:   broker.PostCreationTopic(),
+		Key:     message.MessageId,
+		Payload: payload,
+	})
+}
+
+// StartOutboxRelay 启动发件箱转发任务
+func StartOutboxRelay(ctx context.Context) {
+	if producer == nil {
+		return
+	}
+	interval, batchSize, maxAttempts := defaultOutboxInterval, defaultOutboxBatchSize, defaultOutboxMaxAttempts
+	if conf := settings.Conf.BrokerConfig; conf != nil {
+		if conf.OutboxInterval > 0 {
+			interval = conf.OutboxInterval
+		}
+		if conf.OutboxBatchSize > 0 {
+			batchSize = conf.OutboxBatchSize
+		}
+		if conf.OutboxMaxAttempts > 0 {
+			maxAttempts = conf.OutboxMaxAttempts
+		}
+	}
+	zap.L().Info("kafka 发件箱转发任务已启动",
+		zap.Duration("interval", interval), zap.Int("batch_size", batchSize), zap.Int("max_attempts", maxAttempts))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(outboxCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			zap.L().Info("kafka 发件箱转发任务已停止")
+			return
+		case <-ticker.C:
+			// 一批发送完后立即发送下一批，直到积压清空或发送失败
+			for {
+				if relayOutbox(ctx, batchSize, maxAttempts) < batchSize {
+					break
+				}
+			}
+		case <-cleanup.C:
+			deleted, err := dao.DeleteSentOutboxMessages(time.Now().Add(-outboxRetention))
+			if err != nil {
+				zap.L().Error("清理已发送的发件箱消息失败", zap.Error(err))
+			} else if deleted > 0 {
+				zap.L().Info("已清理发件箱消息", zap.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// 转发一批消息，返回发送成功的数量
+// 整批发送失败时逐条重试，只有发送失败的消息累加失败次数，无法发送的消息不会拖住同一批的其他消息
+func relayOutbox(ctx context.Context, batchSize, maxAttempts int) int {
+	msgs, err := dao.ClaimOutboxMessages(batchSize, outboxLease)
+	if err != nil {
+		recordRelay(0, 0, err)
+		return 0
+	}
+	if len(msgs) == 0 {
+		recordRelay(0, 0, nil)
+		return 0
+	}
+
+	var sentIDs []int64
+	failures := make(map[*entity.OutboxMessage]error)
+	if err := publishOutbox(ctx, msgs...); err == nil {
+		for _, msg := range msgs {
+			sentIDs = append(sentIDs, msg.ID)
+		}
+	} else {
+		for _, msg := range msgs {
+			if err := publishOutbox(ctx, msg); err != nil {
+				failures[msg] = err
+				continue
+			}
+			sentIDs = append(sentIDs, msg.ID)
+		}
+	}
+
+	// 标记失败时出错的消息在租约到期后重新发送
+	if err := dao.MarkOutboxMessagesSent(sentIDs); err != nil {
+		zap.L().Error("标记发件箱消息已发送失败", zap.Error(err))
+	}
+	var lastErr error
+	for msg, publishErr := range failures {
+		lastErr = publishErr
+		parked, err := dao.MarkOutboxMessageFailed(msg, publishErr.Error(), maxAttempts)
+		if err != nil {
+			zap.L().Error("记录发件箱消息发送失败出错", zap.Int64("id", msg.ID), zap.Error(err))
+			continue
+		}
+		if parked {
+			zap.L().Error("发件箱消息多次发送失败，已搁置",
+				zap.Int64("id", msg.ID), zap.String("topic", msg.Topic), zap.Error(publishErr))
+		}
+	}
+
+	recordRelay(len(sentIDs), len(failures), lastErr)
+	return len(sentIDs)
+}
+
+// 把发件箱消息发送到消息队列
+func publishOutbox(ctx context.Context, msgs ...*entity.OutboxMessage) error {
+	brokerMsgs := make([]*broker.Message, len(msgs))
+	for i, msg := range msgs {
+		brokerMsgs[i] = &broker.Message{
+			Topic:   msg.Topic,
+			Key:     msg.Key,
+			Payload: msg.Payload,
+			Time:    msg.CreatedAt,
+		}
+	}
+	return producer.publisher.Publish(ctx, brokerMsgs...)
+}
+
+// 记录转发指标
+func recordRelay(sent, failed int, err error) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	lastRelayAt = time.Now()
+	sentTotal += int64(sent)
+	failedTotal += int64(failed)
+	if err != nil {
+		lastError = err.Error()
+		zap.L().Error("转发发件箱消息失败", zap.Error(err))
+	}
+}
+
+// GetOutboxStats 查询发件箱转发指标
+func GetOutboxStats() (*OutboxStats, error) {
+	if producer == nil {
+		return nil, constants.ErrKafkaNotEnabled
+	}
+	pending, oldest, err := dao.GetOutboxLag()
+	if err != nil {
+		return nil, err
+	}
+	parked, err := dao.CountParkedOutboxMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	statsMu.Lock()
+	stats := &OutboxStats{
+		Pending:     pending,
+		Parked:      parked,
+		SentTotal:   sentTotal,
+		FailedTotal: failedTotal,
+		LastError:   lastError,
+	}
+	if !lastRelayAt.IsZero() {
+		stats.LastRelayAt = lastRelayAt.Format("2006-01-02 15:04:05")
+	}
+	statsMu.Unlock()
+
+	if oldest != nil {
+		stats.OldestPendingAt = oldest.Format("2006-01-02 15:04:05")
+		stats.OldestWaitSecs = time.Since(*oldest).Seconds()
+	}
+	return stats, nil
+}
@@ -3,61 +3,41 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"vision/constants"
 	"vision/models/proto"
-	"vision/settings"
+	"vision/service/broker"
 
-	"github.com/segmentio/kafka-go"
 	protobuf "google.golang.org/protobuf/proto" // 导入 Protobuf 包
 )
 
 var (
-	producer *Producer
-	//producerOnce sync.Once
+	producer     *Producer
+	producerOnce sync.Once
 )
 
-// Producer 定义 Kafka 生产者结构体
+// Producer 定义消息生产者结构体，按配置使用 kafka / pulsar / rocketmq / memory 后端
 type Producer struct {
-	config *settings.KafkaConfig
-	writer *kafka.Writer
+	publisher broker.Publisher
 }
 
-// InitProducer 初始化全局生产者（单例模式）
-//func InitProducer() error {
-//	producerOnce.Do(func() {
-//		conf := settings.Conf.KafkaConfig
-//		if !conf.Enabled {
-//			return // 未启用则不初始化
-//		}
-//
-//		writer := &kafka.Writer{
-//			Addr:         kafka.TCP(conf.Brokers...),
-//			Topic:        conf.TopicPostCreation,
-//			Balancer:     &kafka.LeastBytes{},
-//			MaxAttempts:  conf.RetryMax,
-//			WriteTimeout: conf.WriteTimeout,
-//			ReadTimeout:  conf.ReadTimeout,
-//			RequiredAcks: kafka.RequireOne,
-//			Compression:  kafka.Zstd,
-//			//Async:        true,  // 启用异步模式
-//		}
-//
-//		producer = &Producer{
-//			config: conf,
-//			writer: writer,
-//		}
-//	})
-//
-//	// 检查是否初始化成功（未启用时 producer 为 nil）
-//	if producer != nil && producer.writer == nil {
-//		return constants.ErrKafkaNotEnabled
-//	}
-//	return nil
-//}
+// InitProducer 初始化全局生产者（单例模式），未启用消息队列时不初始化
+func InitProducer() error {
+	var err error
+	producerOnce.Do(func() {
+		var publisher broker.Publisher
+		publisher, err = broker.NewPublisher()
+		if err != nil || publisher == nil {
+			return
+		}
+		producer = &Producer{publisher: publisher}
+	})
+	return err
+}
 
 // Produce 发送消息到 Kafka（实例方法）
 func (p *Producer) ProduceUseJson(message PostCreationMessage) error {
-	if p == nil || p.writer == nil || !p.config.Enabled {
+	if p == nil {
 		return constants.ErrKafkaNotEnabled
 	}
 
@@ -66,16 +46,17 @@ func (p *Producer) ProduceUseJson(message PostCreationMessage) error {
 		return err
 	}
 
-	return p.writer.WriteMessages(context.Background(), kafka.Message{
-		Key:   []byte(message.MessageID),
-		Value: msgBytes,
-		Time:  message.CreatedAt,
+	return p.publisher.Publish(context.Background(), &broker.Message{
+		Topic:   broker.PostCreationTopic(),
+		Key:     message.MessageID,
+		Payload: msgBytes,
+		Time:    message.CreatedAt,
 	})
 }
 
 // Produce 发送消息到 Kafka（实例方法）
 func (p *Producer) Produce(message *proto.PostCreationMessage) error {
-	if p == nil || p.writer == nil || !p.config.Enabled {
+	if p == nil {
 		return constants.ErrKafkaNotEnabled
 	}
 
@@ -85,10 +66,11 @@ func (p *Producer) Produce(message *proto.PostCreationMessage) error {
 		return err
 	}
 
-	return p.writer.WriteMessages(context.Background(), kafka.Message{
-		Key:   []byte(message.MessageId),
-		Value: msgBytes,
-		Time:  message.CreatedAt.AsTime(),
+	return p.publisher.Publish(context.Background(), &broker.Message{
+		Topic:   broker.PostCreationTopic(),
+		Key:     message.MessageId,
+		Payload: msgBytes,
+		Time:    message.CreatedAt.AsTime(),
 	})
 }
 
@@ -101,22 +83,13 @@ func SendPostCreationMessageUseJson(message PostCreationMessage) error {
 	return producer.ProduceUseJson(message)
 }
 
-// SendPostCreationMessage 发送消息到 Kafka（全局方法）
-//func SendPostCreationMessage(message *proto.PostCreationMessage) error {
-//	if producer == nil {
-//		return constants.ErrKafkaNotEnabled
-//	}
-//
-//	return producer.Produce(message)
-//}
-
 // Close 关闭生产者连接（实例方法）
-//func (p *Producer) Close() error {
-//	if p == nil || p.writer == nil {
-//		return nil
-//	}
-//	return p.writer.Close()
-//}
+func (p *Producer) Close() error {
+	if p == nil {
+		return nil
+	}
+	return p.publisher.Close()
+}
 
 // CloseProducer 关闭全局生产者连接
 func CloseProducer() error {
@@ -0,0 +1,65 @@
+package mention
+
+import (
+	"vision/dao"
+	"vision/models/entity"
+	"vision/pkg/mention"
+)
+
+// 解析帖子和评论中的 @提及：用户名解析为用户id后保存位置，并给被提及的用户发送通知
+// 编辑内容时重新保存提及，通知按事件去重，只有新增的被提及用户会收到通知
+
+// Save 解析内容中的提及并替换该内容原有的提及记录，提及文本按最长的已存在用户名匹配，没有匹配的忽略
+// commentID 为0表示帖子正文
+func Save(postID, commentID, authorID int64, content string) ([]*entity.Mention, error) {
+	spans := mention.Parse(content)
+	userIDs, err := dao.GetUserIDsByUsernames(mention.Candidates(spans))
+	if err != nil {
+		return nil, err
+	}
+	spans = mention.Resolve(spans, func(username string) bool {
+		_, ok := userIDs[username]
+		return ok
+	})
+
+	mentions := make([]*entity.Mention, 0, len(spans))
+	for _, span := range spans {
+		userID := userIDs[span.Username]
+		mentions = append(mentions, &entity.Mention{
+			PostID:    postID,
+			CommentID: commentID,
+			Start:     span.Start,
+			End:       span.End,
+			UserID:    userID,
+			Username:  span.Username,
+			AuthorID:  authorID,
+		})
+	}
+	if err := dao.ReplaceMentions(postID, commentID, mentions); err != nil {
+		return nil, err
+	}
+	return mentions, nil
+}
+
+// Notify 给被提及的用户发送通知，每个用户只通知一次，不通知作者本人
+func Notify(mentions []*entity.Mention) error {
+	seen := make(map[int64]struct{}, len(mentions))
+	notifications := make([]*entity.Notification, 0, len(mentions))
+	for _, m := range mentions {
+		if m.UserID == m.AuthorID {
+			continue
+		}
+		if _, ok := seen[m.UserID]; ok {
+			continue
+		}
+		seen[m.UserID] = struct{}{}
+		notifications = append(notifications, &entity.Notification{
+			UserID:    m.UserID,
+			Type:      entity.NotificationTypeMention,
+			ActorID:   m.AuthorID,
+			PostID:    m.PostID,
+			CommentID: m.CommentID,
+		})
+	}
+	return dao.CreateNotifications(notifications)
+}
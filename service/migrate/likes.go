@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/dao/redis"
+)
+
+// 用户点赞记录迁移：旧版本用 set（user_liked:posts:{userID}）保存点赞的帖子，查询点赞列表时需要遍历全部帖子
+// 新版本改为按点赞时间排序的 zset（user:liked:posts:{userID}），以数据库中的投票记录（direction=1）为准生成，
+// 点赞时间为投票记录的更新时间；旧版本的点赞集合只用于统计不再迁移的记录，迁移后删除
+
+// 每批迁移的用户数量
+const likesBatchSize = 500
+
+// LikesReport 点赞记录迁移结果
+type LikesReport struct {
+	Users    int `json:"users"`    // 迁移的用户数
+	Migrated int `json:"migrated"` // 迁移的点赞记录数
+	Dropped  int `json:"dropped"`  // 旧版本集合中有、数据库中已不存在的点赞记录（不迁移）
+}
+
+// MigrateUserLikes 根据数据库中的投票记录生成点赞有序集合，迁移完成后写入标记，不会重复执行
+func MigrateUserLikes(ctx context.Context) (*LikesReport, error) {
+	report := &LikesReport{}
+	migrated, err := redis.IsUserLikesMigrated()
+	if err != nil || migrated {
+		return report, err
+	}
+
+	var lastUserID int64
+	for {
+		userIDs, err := dao.GetLikerUserIDBatch(lastUserID, likesBatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+		for _, userID := range userIDs {
+			select {
+			case <-ctx.Done():
+				return report, ctx.Err()
+			default:
+			}
+			if err := migrateUserLikes(userID, report); err != nil {
+				return report, err
+			}
+		}
+		lastUserID = userIDs[len(userIDs)-1]
+	}
+
+	// 数据库中已没有点赞记录的用户，旧版本的点赞集合直接删除
+	legacyUserIDs, err := redis.GetLegacyLikedUserIDs()
+	if err != nil {
+		return report, err
+	}
+	for _, userID := range legacyUserIDs {
+		postIDs, err := redis.GetLegacyLikedPostIDs(userID)
+		if err != nil {
+			return report, err
+		}
+		report.Dropped += len(postIDs)
+		if err := redis.MigrateLegacyLikedPosts(userID, nil); err != nil {
+			return report, err
+		}
+	}
+
+	return report, redis.SetUserLikesMigrated()
+}
+
+// 迁移单个用户的点赞记录
+func migrateUserLikes(userID int64, report *LikesReport) error {
+	likeTimes, err := dao.GetUserLikeTimes(userID)
+	if err != nil {
+		return err
+	}
+	legacyPostIDs, err := redis.GetLegacyLikedPostIDs(userID)
+	if err != nil {
+		return err
+	}
+	for _, postID := range legacyPostIDs {
+		if _, ok := likeTimes[postID]; !ok {
+			report.Dropped++
+		}
+	}
+
+	likedAt := make(map[string]float64, len(likeTimes))
+	for postID, t := range likeTimes {
+		likedAt[postID] = float64(t.Unix())
+	}
+	// 已存在的点赞记录保持不变，迁移期间产生的新点赞不会被覆盖
+	if err := redis.MigrateLegacyLikedPosts(userID, likedAt); err != nil {
+		return err
+	}
+
+	// 查询投票记录之后取消的点赞可能被上面的写入重新加回，写入后再次查询数据库并移除这些帖子
+	// 取消点赞先删除数据库记录再更新 redis，因此写入之后才取消的点赞会由投票流程自行移除
+	current, err := dao.GetUserLikeTimes(userID)
+	if err != nil {
+		return err
+	}
+	var unliked []string
+	for postID := range likedAt {
+		if _, ok := current[postID]; !ok {
+			unliked = append(unliked, postID)
+		}
+	}
+	if err := redis.RemoveUserLikedPosts(userID, unliked); err != nil {
+		return err
+	}
+
+	report.Users++
+	report.Migrated += len(likedAt) - len(unliked)
+	return nil
+}
+
+// StartUserLikesMigration 启动时在后台执行一次点赞记录迁移
+func StartUserLikesMigration(ctx context.Context) {
+	report, err := MigrateUserLikes(ctx)
+	if err != nil {
+		zap.L().Error("迁移用户点赞记录失败", zap.Error(err))
+		return
+	}
+	if report.Users > 0 {
+		zap.L().Info("迁移用户点赞记录完成",
+			zap.Int("users", report.Users),
+			zap.Int("migrated", report.Migrated),
+			zap.Int("dropped", report.Dropped))
+	}
+}
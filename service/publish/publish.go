@@ -0,0 +1,105 @@
+package publish
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+	"vision/service/mention"
+	"vision/service/ranking"
+)
+
+const (
+	scheduleInterval  = time.Minute // 定时发布检查间隔
+	scheduleBatchSize = 100         // 每批发布的帖子数量
+)
+
+// Publish 发布帖子：先更新数据库状态，再写入 redis 的排序集合、社区集合和标签集合
+func Publish(post *entity.Post) error {
+	now := time.Now()
+	ok, err := dao.MarkPostPublished(post.ID, now)
+	if err != nil {
+		return err
+	}
+	if !ok { // 已被其他流程发布
+		return nil
+	}
+	post.Status = entity.PostStatusPublished
+	post.PublishAt = &now
+	post.CreatedAt = now
+
+	if err := redis.CreatePost(post.ID, post.CommunityID); err != nil {
+		return err
+	}
+
+	tags, err := dao.GetPostTagNames([]int64{post.ID})
+	if err != nil {
+		return err
+	}
+	if err := redis.AddPostTags(post.ID, tags[post.ID], now); err != nil {
+		return err
+	}
+
+	// 草稿保存时已记录提及，发布时通知被提及的用户
+	mentions, err := dao.GetPostMentions([]int64{post.ID})
+	if err != nil {
+		return err
+	}
+	if err := mention.Notify(mentions); err != nil {
+		zap.L().Error("发送提及通知失败", zap.Int64("post_id", post.ID), zap.Error(err))
+	}
+
+	// 按当前排序算法计算初始热度
+	return ranking.RefreshPost(post)
+}
+
+// StartScheduler 启动定时发布任务：定期发布到达发布时间的帖子
+func StartScheduler(ctx context.Context) {
+	zap.L().Info("帖子定时发布任务已启动", zap.Duration("interval", scheduleInterval))
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := PublishDuePosts()
+		if err != nil {
+			zap.L().Error("定时发布帖子失败", zap.Error(err))
+		} else if n > 0 {
+			zap.L().Info("定时发布帖子完成", zap.Int("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			zap.L().Info("帖子定时发布任务已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDuePosts 发布所有到达发布时间的帖子，返回发布的数量
+func PublishDuePosts() (int, error) {
+	now := time.Now()
+	total := 0
+	for {
+		posts, err := dao.GetDuePosts(now, scheduleBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(posts) == 0 {
+			return total, nil
+		}
+
+		for _, post := range posts {
+			if err := Publish(post); err != nil {
+				// 单个帖子失败时停止本轮，避免反复查询到同一批帖子
+				return total, err
+			}
+			total++
+		}
+	}
+}
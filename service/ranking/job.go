@@ -0,0 +1,169 @@
+package ranking
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+	"vision/settings"
+)
+
+// 每批重算的帖子数量
+const recomputeBatchSize = 500
+
+var (
+	current           Ranker // 当前使用的排序算法，nil 表示 legacy
+	window            = defaultWindow
+	recomputeInterval = defaultRecomputeInterval
+)
+
+// Init 根据配置选择排序算法
+func Init(cfg *settings.RankingConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	ranker, err := newRanker(cfg)
+	if err != nil {
+		return err
+	}
+	current = ranker
+
+	if cfg.Window > 0 {
+		window = cfg.Window
+	}
+	if cfg.RecomputeInterval > 0 {
+		recomputeInterval = cfg.RecomputeInterval
+	}
+	return nil
+}
+
+// Enabled 是否启用了时间衰减的排序算法
+func Enabled() bool {
+	return current != nil
+}
+
+// Score 按当前排序算法计算帖子热度分数，未启用时返回0
+func Score(ups, downs int64, createdAt time.Time) float64 {
+	if current == nil {
+		return 0
+	}
+	return score(ups, downs, createdAt, time.Now())
+}
+
+// 计算帖子热度分数，超出时间窗口的帖子不再参与热度排序，使用保底分数
+func score(ups, downs int64, createdAt, now time.Time) float64 {
+	if now.Sub(createdAt) > window {
+		return agedOutScore
+	}
+	return current.Score(ups, downs, createdAt, now)
+}
+
+// RefreshPost 发帖或投票后立即重算单个帖子的热度分数（legacy 算法下不做处理）
+func RefreshPost(post *entity.Post) error {
+	if current == nil {
+		return nil
+	}
+	return rescore([]*entity.Post{post}, time.Now())
+}
+
+// StartRecomputeJob 启动定时重算任务：启动时全量重算一次，之后每隔一段时间重算时间窗口内的帖子，
+// 以及上次重算之后离开时间窗口的帖子（改为保底分数，避免旧帖子一直停留在最后一次计算的分数上）
+func StartRecomputeJob(ctx context.Context) {
+	if current == nil {
+		return
+	}
+
+	zap.L().Info("帖子热度重算任务已启动",
+		zap.String("algorithm", current.Name()),
+		zap.Duration("window", window),
+		zap.Duration("interval", recomputeInterval))
+
+	// 切换算法后旧分数与新分数不可比较，启动时全量重算
+	lastRun := time.Now()
+	if n, err := Recompute(time.Time{}); err != nil {
+		zap.L().Error("全量重算帖子热度失败", zap.Error(err))
+		lastRun = time.Time{} // 全量重算失败时，下次继续全量重算
+	} else {
+		zap.L().Info("全量重算帖子热度完成", zap.Int("count", n))
+	}
+
+	ticker := time.NewTicker(recomputeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			zap.L().Info("帖子热度重算任务已停止")
+			return
+		case <-ticker.C:
+			// 从上次重算时的窗口起点开始，包含这段时间内离开窗口的帖子
+			now := time.Now()
+			since := time.Time{}
+			if !lastRun.IsZero() {
+				since = lastRun.Add(-window)
+			}
+			n, err := Recompute(since)
+			if err != nil {
+				zap.L().Error("重算帖子热度失败", zap.Error(err))
+				continue
+			}
+			lastRun = now
+			zap.L().Debug("重算帖子热度完成", zap.Int("count", n))
+		}
+	}
+}
+
+// Recompute 重算指定时间之后发布的所有帖子的热度分数，返回处理的帖子数
+func Recompute(since time.Time) (int, error) {
+	if current == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	total := 0
+	var lastID int64
+	for {
+		posts, err := dao.GetPostsCreatedAfter(since, lastID, recomputeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(posts) == 0 {
+			return total, nil
+		}
+
+		if err := rescore(posts, now); err != nil {
+			return total, err
+		}
+		total += len(posts)
+		lastID = posts[len(posts)-1].ID
+	}
+}
+
+// 计算一批帖子的热度分数并写回 redis
+func rescore(posts []*entity.Post, now time.Time) error {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = strconv.FormatInt(post.ID, 10)
+	}
+
+	ups, downs, err := redis.GetPostVoteCountsByIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	scores := make([]redis.PostScore, len(posts))
+	for i, post := range posts {
+		scores[i] = redis.PostScore{
+			PostID:      post.ID,
+			CommunityID: post.CommunityID,
+			Score:       score(ups[i], downs[i], post.CreatedAt, now),
+		}
+	}
+	return redis.SetPostScores(scores)
+}
@@ -0,0 +1,84 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"vision/settings"
+)
+
+// 支持的排序算法
+const (
+	AlgorithmLegacy     = "legacy"     // 原有算法：创建时间戳 + 每票固定分数，不随时间衰减
+	AlgorithmReddit     = "reddit"     // Reddit 对数热度算法
+	AlgorithmHackerNews = "hackernews" // Hacker News 重力衰减算法
+)
+
+const (
+	defaultGravity           = 1.8
+	defaultWindow            = 7 * 24 * time.Hour
+	defaultRecomputeInterval = 10 * time.Minute
+
+	redditEpoch = 1134028003 // Reddit 算法的起始时间（2005-12-08）
+
+	// 超出时间窗口的帖子统一使用的保底分数，低于窗口内任何帖子的分数
+	// 同分的帖子 redis 按成员（帖子id）排序，雪花id按时间递增，因此超出窗口的帖子之间仍按发布时间排列
+	agedOutScore = -1e9
+)
+
+// Ranker 根据票数和发布时间计算帖子热度分数，分数越大越靠前
+type Ranker interface {
+	Name() string
+	Score(ups, downs int64, createdAt, now time.Time) float64
+}
+
+// Reddit 热度算法：净票数取对数，发布时间线性加分，每 12.5 小时相当于 10 倍票数
+type redditRanker struct{}
+
+func (redditRanker) Name() string { return AlgorithmReddit }
+
+func (redditRanker) Score(ups, downs int64, createdAt, _ time.Time) float64 {
+	s := float64(ups - downs)
+	order := math.Log10(math.Max(math.Abs(s), 1))
+
+	sign := 0.0
+	if s > 0 {
+		sign = 1
+	} else if s < 0 {
+		sign = -1
+	}
+
+	seconds := float64(createdAt.Unix() - redditEpoch)
+	return sign*order + seconds/45000
+}
+
+// Hacker News 热度算法：净票数除以（帖子年龄+2）的 gravity 次方，分数随时间持续衰减
+type hackerNewsRanker struct {
+	gravity float64
+}
+
+func (hackerNewsRanker) Name() string { return AlgorithmHackerNews }
+
+func (r hackerNewsRanker) Score(ups, downs int64, createdAt, now time.Time) float64 {
+	hours := math.Max(now.Sub(createdAt).Hours(), 0)
+	return float64(ups-downs) / math.Pow(hours+2, r.gravity)
+}
+
+// 根据配置创建排序算法，legacy 返回 nil
+func newRanker(cfg *settings.RankingConfig) (Ranker, error) {
+	switch cfg.Algorithm {
+	case "", AlgorithmLegacy:
+		return nil, nil
+	case AlgorithmReddit:
+		return redditRanker{}, nil
+	case AlgorithmHackerNews:
+		gravity := cfg.Gravity
+		if gravity <= 0 {
+			gravity = defaultGravity
+		}
+		return hackerNewsRanker{gravity: gravity}, nil
+	default:
+		return nil, fmt.Errorf("unknown ranking algorithm: %s", cfg.Algorithm)
+	}
+}
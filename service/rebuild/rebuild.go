@@ -0,0 +1,465 @@
+package rebuild
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+	"vision/service/ranking"
+)
+
+// 根据数据库中的帖子、评论和投票重建 redis 中的排序和计数数据
+// 所有写入都是覆盖式的，重复执行结果一致；dryRun 时只对比不写入
+
+// 每批处理的帖子数量
+const batchSize = 500
+
+// KeyReport 一类 key 的差异汇总
+type KeyReport struct {
+	Keys       int `json:"keys"`       // 检查的 key 数量
+	Changed    int `json:"changed"`    // 存在差异的 key 数量
+	Missing    int `json:"missing"`    // 缺失的成员数
+	Mismatched int `json:"mismatched"` // 分数不一致的成员数
+	Extra      int `json:"extra"`      // 多余的成员数
+}
+
+// Report 重建结果
+type Report struct {
+	DryRun   bool                  `json:"dry_run"`
+	Posts    int                   `json:"posts"`    // 扫描的帖子数
+	Comments int                   `json:"comments"` // 扫描的评论数
+	Votes    int                   `json:"votes"`    // 扫描的投票数
+	Keys     map[string]*KeyReport `json:"keys"`     // key 类别 -> 差异汇总
+	Duration string                `json:"duration"` // 耗时
+}
+
+func (r *Report) add(family string, result *redis.SyncResult) {
+	kr, ok := r.Keys[family]
+	if !ok {
+		kr = &KeyReport{}
+		r.Keys[family] = kr
+	}
+	kr.Keys++
+	if result.Changed() {
+		kr.Changed++
+	}
+	kr.Missing += result.Missing
+	kr.Mismatched += result.Mismatched
+	kr.Extra += result.Extra
+}
+
+// 扫描过程中累积的全局 key 的期望状态
+type state struct {
+	postTime       map[string]float64
+	postScore      map[string]float64
+	postCommentNum map[string]float64
+	commentNum     map[string]float64
+	archivedUp     map[string]int64
+	archivedDown   map[string]int64
+	communityPosts map[int64]map[string]struct{}
+	communityHot   map[int64]map[string]float64
+	userLiked      map[int64]map[string]float64
+	tagTime        map[string]map[string]float64
+	tagScore       map[string]map[string]float64
+}
+
+// Run 执行重建，dryRun 为 true 时只报告差异
+func Run(dryRun bool) (*Report, error) {
+	begin := time.Now()
+	report := &Report{
+		DryRun: dryRun,
+		Keys:   make(map[string]*KeyReport),
+	}
+	st := &state{
+		postTime:       make(map[string]float64),
+		postScore:      make(map[string]float64),
+		postCommentNum: make(map[string]float64),
+		commentNum:     make(map[string]float64),
+		archivedUp:     make(map[string]int64),
+		archivedDown:   make(map[string]int64),
+		communityPosts: make(map[int64]map[string]struct{}),
+		communityHot:   make(map[int64]map[string]float64),
+		userLiked:      make(map[int64]map[string]float64),
+		tagTime:        make(map[string]map[string]float64),
+		tagScore:       make(map[string]map[string]float64),
+	}
+
+	// 没有帖子的社区也需要清理，先登记所有社区
+	communities, err := dao.GetCommunityList()
+	if err != nil && !errors.Is(err, constants.ErrorNoResult) {
+		return nil, err
+	}
+	for _, community := range communities {
+		st.communityPosts[community.ID] = make(map[string]struct{})
+		st.communityHot[community.ID] = make(map[string]float64)
+	}
+
+	// 分批扫描帖子，按帖子维度的 key 在批内直接同步
+	var lastID int64
+	for {
+		posts, err := dao.GetPostBatch(lastID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(posts) == 0 {
+			break
+		}
+		if err := rebuildBatch(posts, st, report); err != nil {
+			return nil, err
+		}
+		lastID = posts[len(posts)-1].ID
+	}
+
+	// 同步全局 key
+	if err := syncGlobal(st, report); err != nil {
+		return nil, err
+	}
+
+	report.Duration = time.Since(begin).String()
+	zap.L().Info("重建 redis 数据完成",
+		zap.Bool("dry_run", dryRun),
+		zap.Int("posts", report.Posts),
+		zap.Int("comments", report.Comments),
+		zap.Int("votes", report.Votes),
+		zap.Any("keys", report.Keys))
+	return report, nil
+}
+
+// 处理一批帖子
+func rebuildBatch(posts []*entity.Post, st *state, report *Report) error {
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	votes, err := dao.GetPostVotesByPostIDs(postIDs)
+	if err != nil {
+		return err
+	}
+	comments, err := dao.GetCommentsByPostIDs(postIDs)
+	if err != nil {
+		return err
+	}
+	tagNames, err := dao.GetPostTagNames(postIDs)
+	if err != nil {
+		return err
+	}
+	report.Posts += len(posts)
+	report.Votes += len(votes)
+	report.Comments += len(comments)
+
+	votesByPost := make(map[int64][]*entity.PostVote)
+	for _, vote := range votes {
+		votesByPost[vote.PostID] = append(votesByPost[vote.PostID], vote)
+	}
+	commentsByPost := make(map[int64][]*entity.Comment)
+	for _, comment := range comments {
+		commentsByPost[comment.PostID] = append(commentsByPost[comment.PostID], comment)
+	}
+
+	for _, post := range posts {
+		if err := rebuildPostVotes(post, votesByPost[post.ID], st, report); err != nil {
+			return err
+		}
+		if err := rebuildPostComments(post, commentsByPost[post.ID], st, report); err != nil {
+			return err
+		}
+		if err := rebuildPostTags(post, tagNames[post.ID], st, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 重建单个帖子的时间、分数、社区归属和投票记录
+func rebuildPostVotes(post *entity.Post, votes []*entity.PostVote, st *state, report *Report) error {
+	postIDStr := strconv.FormatInt(post.ID, 10)
+	createdAt := float64(post.CreatedAt.Unix())
+
+	st.postTime[postIDStr] = createdAt
+	if _, ok := st.communityPosts[post.CommunityID]; !ok {
+		st.communityPosts[post.CommunityID] = make(map[string]struct{})
+		st.communityHot[post.CommunityID] = make(map[string]float64)
+	}
+	st.communityPosts[post.CommunityID][postIDStr] = struct{}{}
+
+	voted := make(map[string]float64, len(votes))
+	var ups, downs int64
+	for _, vote := range votes {
+		if vote.Direction == 0 {
+			continue
+		}
+		voted[strconv.FormatInt(vote.UserID, 10)] = float64(vote.Direction)
+		if vote.Direction == 1 {
+			ups++
+			if _, ok := st.userLiked[vote.UserID]; !ok {
+				st.userLiked[vote.UserID] = make(map[string]float64)
+			}
+			st.userLiked[vote.UserID][postIDStr] = float64(vote.UpdatedAt.Unix())
+		} else {
+			downs++
+		}
+	}
+
+	// 已归档的帖子不再保留 post:voted，票数以归档计数为准
+	archived := post.VotesArchivedAt != nil
+	if archived {
+		ups, downs = post.LikeCount, post.DislikeCount
+		st.archivedUp[postIDStr] = ups
+		st.archivedDown[postIDStr] = downs
+		voted = map[string]float64{}
+	}
+	result, err := redis.SyncZSet(redis.KeyPostVotedZSetPF+postIDStr, voted, report.DryRun)
+	if err != nil {
+		return err
+	}
+	report.add(redis.KeyPostVotedZSetPF+"{postID}", result)
+
+	// 计算热度分数
+	switch {
+	case ranking.Enabled():
+		score := ranking.Score(ups, downs, post.CreatedAt)
+		st.postScore[postIDStr] = score
+		st.communityHot[post.CommunityID][postIDStr] = score
+	case archived && post.FinalScore != 0:
+		st.postScore[postIDStr] = post.FinalScore
+	default:
+		st.postScore[postIDStr] = legacyScore(post, votes)
+	}
+	return nil
+}
+
+// 重建单个帖子的标签集合，并记录标签的时间和热度集合中应有的成员（与 post:time、post:score 分数一致）
+func rebuildPostTags(post *entity.Post, tags []string, st *state, report *Report) error {
+	postIDStr := strconv.FormatInt(post.ID, 10)
+
+	members := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		members[tag] = struct{}{}
+		if _, ok := st.tagTime[tag]; !ok {
+			st.tagTime[tag] = make(map[string]float64)
+			st.tagScore[tag] = make(map[string]float64)
+		}
+		st.tagTime[tag][postIDStr] = st.postTime[postIDStr]
+		st.tagScore[tag][postIDStr] = st.postScore[postIDStr]
+	}
+
+	result, err := redis.SyncSet(redis.KeyPostTagsSetPF+postIDStr, members, report.DryRun)
+	if err != nil {
+		return err
+	}
+	report.add(redis.KeyPostTagsSetPF+"{postID}", result)
+	return nil
+}
+
+// 按 legacy 算法计算帖子分数：发布时间戳 + 每票固定分数（发布一周后投的票权重降低）
+func legacyScore(post *entity.Post, votes []*entity.PostVote) float64 {
+	score := float64(post.CreatedAt.Unix())
+	for _, vote := range votes {
+		weight := 1.0
+		if vote.UpdatedAt.Sub(post.CreatedAt).Seconds() > constants.OneWeekInSeconds {
+			weight = 0.8
+		}
+		score += float64(vote.Direction) * constants.ScorePerVote * weight
+	}
+	return score
+}
+
+// 重建单个帖子的评论时间、评论分数和评论计数
+func rebuildPostComments(post *entity.Post, comments []*entity.Comment, st *state, report *Report) error {
+	postIDStr := strconv.FormatInt(post.ID, 10)
+	st.postCommentNum[postIDStr] = 0
+
+	// 墓碑评论保留在评论列表中，但不计入评论数和回复数
+	commentTime := make(map[string]float64)
+	commentScore := make(map[string]float64)
+	replyScore := make(map[string]map[string]float64) // rootID -> 子评论id -> 初始分数
+	for _, comment := range comments {
+		if comment.TombstonedAt == nil {
+			st.postCommentNum[postIDStr]++
+		}
+		commentIDStr := strconv.FormatInt(comment.ID, 10)
+		if comment.RootID == nil {
+			commentTime[commentIDStr] = float64(comment.CreatedAt.Unix())
+			commentScore[commentIDStr] = float64(comment.CreatedAt.Unix())
+			if _, ok := st.commentNum[commentIDStr]; !ok {
+				st.commentNum[commentIDStr] = 0
+			}
+			if _, ok := replyScore[commentIDStr]; !ok {
+				replyScore[commentIDStr] = make(map[string]float64)
+			}
+			continue
+		}
+		rootIDStr := strconv.FormatInt(*comment.RootID, 10)
+		if comment.TombstonedAt == nil {
+			st.commentNum[rootIDStr]++
+		}
+		if _, ok := replyScore[rootIDStr]; !ok {
+			replyScore[rootIDStr] = make(map[string]float64)
+		}
+		replyScore[rootIDStr][commentIDStr] = float64(comment.CreatedAt.Unix())
+	}
+
+	result, err := redis.SyncZSet(redis.KeyCommentTimeZSetPF+postIDStr, commentTime, report.DryRun)
+	if err != nil {
+		return err
+	}
+	report.add(redis.KeyCommentTimeZSetPF+"{postID}", result)
+
+	// 评论的投票只保存在 redis 中，已有成员保留原分数，缺失的成员以发布时间为初始分数
+	result, err = redis.SyncZSetMembers(redis.KeyCommentScoreZSetPF+postIDStr, commentScore, report.DryRun)
+	if err != nil {
+		return err
+	}
+	report.add(redis.KeyCommentScoreZSetPF+"{postID}", result)
+
+	// 子评论的投票分数同样只保存在 redis 中
+	for rootIDStr, expected := range replyScore {
+		result, err = redis.SyncZSetMembers(redis.KeyCommentReplyScoreZSetPF+rootIDStr, expected, report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(redis.KeyCommentReplyScoreZSetPF+"{rootID}", result)
+	}
+	return nil
+}
+
+// 同步全局 key 及按社区、用户划分的 key
+func syncGlobal(st *state, report *Report) error {
+	zsets := []struct {
+		key      string
+		expected map[string]float64
+	}{
+		{redis.KeyPostTimeZSet, st.postTime},
+		{redis.KeyPostScoreZSet, st.postScore},
+		{redis.KeyPostCommentNumZSet, st.postCommentNum},
+		{redis.KeyCommentNumZSet, st.commentNum},
+	}
+	for _, z := range zsets {
+		result, err := redis.SyncZSet(z.key, z.expected, report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(z.key, result)
+	}
+
+	hashes := []struct {
+		key      string
+		expected map[string]int64
+	}{
+		{redis.KeyPostArchivedUpHash, st.archivedUp},
+		{redis.KeyPostArchivedDownHash, st.archivedDown},
+	}
+	for _, h := range hashes {
+		result, err := redis.SyncHash(h.key, h.expected, report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(h.key, result)
+	}
+
+	for communityID, posts := range st.communityPosts {
+		communityIDStr := strconv.FormatInt(communityID, 10)
+		result, err := redis.SyncSet(redis.KeyCommunitySetPF+communityIDStr, posts, report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(redis.KeyCommunitySetPF+"{communityID}", result)
+
+		if ranking.Enabled() {
+			result, err = redis.SyncZSet(redis.KeyCommunityHotZSetPF+communityIDStr, st.communityHot[communityID], report.DryRun)
+			if err != nil {
+				return err
+			}
+			report.add(redis.KeyCommunityHotZSetPF+"{communityID}", result)
+		}
+	}
+
+	for userID, posts := range st.userLiked {
+		result, err := redis.SyncZSet(redis.KeyUserLikedPostsZSetPF+strconv.FormatInt(userID, 10), posts, report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(redis.KeyUserLikedPostsZSetPF+"{userID}", result)
+	}
+
+	for tag, posts := range st.tagTime {
+		result, err := redis.SyncZSet(redis.KeyTagTimeZSetPF+tag, posts, report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(redis.KeyTagTimeZSetPF+"{tag}", result)
+
+		result, err = redis.SyncZSet(redis.KeyTagScoreZSetPF+tag, st.tagScore[tag], report.DryRun)
+		if err != nil {
+			return err
+		}
+		report.add(redis.KeyTagScoreZSetPF+"{tag}", result)
+	}
+
+	// 数据库中已没有对应数据的 key 不会出现在 state 中，扫描 redis 清空这些残留的 key：
+	// 已经没有任何点赞的用户、已没有已发布帖子的标签，以及已删除或未发布帖子的标签集合
+	stale := []struct {
+		prefix string
+		family string
+		isSet  bool
+		keep   func(suffix string) bool
+	}{
+		{redis.KeyUserLikedPostsZSetPF, "{userID}", false, func(suffix string) bool {
+			userID, err := strconv.ParseInt(suffix, 10, 64)
+			if err != nil { // 不是 user:liked:posts:{userID} 格式的 key，跳过
+				return true
+			}
+			_, ok := st.userLiked[userID]
+			return ok
+		}},
+		{redis.KeyTagTimeZSetPF, "{tag}", false, func(suffix string) bool {
+			_, ok := st.tagTime[suffix]
+			return ok
+		}},
+		{redis.KeyTagScoreZSetPF, "{tag}", false, func(suffix string) bool {
+			_, ok := st.tagScore[suffix]
+			return ok
+		}},
+		{redis.KeyPostTagsSetPF, "{postID}", true, func(suffix string) bool {
+			_, ok := st.postTime[suffix]
+			return ok
+		}},
+	}
+	for _, k := range stale {
+		if err := clearStaleKeys(k.prefix, k.family, k.isSet, k.keep, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 扫描指定前缀的 key，清空 keep 返回 false 的 key
+func clearStaleKeys(prefix, family string, isSet bool, keep func(suffix string) bool, report *Report) error {
+	suffixes, err := redis.ScanKeySuffixes(prefix)
+	if err != nil {
+		return err
+	}
+	for _, suffix := range suffixes {
+		if keep(suffix) {
+			continue
+		}
+		var result *redis.SyncResult
+		if isSet {
+			result, err = redis.SyncSet(prefix+suffix, nil, report.DryRun)
+		} else {
+			result, err = redis.SyncZSet(prefix+suffix, nil, report.DryRun)
+		}
+		if err != nil {
+			return err
+		}
+		report.add(prefix+family, result)
+	}
+	return nil
+}
@@ -0,0 +1,620 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"vision/constants"
+	"vision/dao"
+	"vision/dao/redis"
+	"vision/models/entity"
+	"vision/service/ranking"
+	"vision/settings"
+)
+
+// 定时对比数据库与 redis，修复因非事务写入导致的缺失成员和计数漂移
+
+// 校验方式
+const (
+	ModeSample = "sample" // 只校验最新的帖子
+	ModeFull   = "full"   // 全量校验
+)
+
+const (
+	defaultInterval   = 30 * time.Minute
+	defaultSampleSize = 1000
+	batchSize         = 500
+	maxSamples        = 20 // 报告中保留的差异示例数量
+)
+
+// DriftReport 一次校验的差异报告
+type DriftReport struct {
+	Mode      string `json:"mode"`
+	DryRun    bool   `json:"dry_run"`
+	StartedAt string `json:"started_at"`
+	Duration  string `json:"duration"`
+
+	PostsChecked        int `json:"posts_checked"`         // 校验的数据库帖子数
+	CommentsChecked     int `json:"comments_checked"`      // 校验的数据库评论数
+	RedisMembersChecked int `json:"redis_members_checked"` // 校验的 post:time 成员数
+
+	MissingPostTime       int `json:"missing_post_time"`        // 不在 post:time 中的帖子
+	MissingPostScore      int `json:"missing_post_score"`       // 不在 post:score 中的帖子
+	MissingCommunity      int `json:"missing_community"`        // 不在 community:{id} 中的帖子
+	MissingPostCommentNum int `json:"missing_post_comment_num"` // 不在 post:comment_num 中的帖子
+	WrongPostCommentNum   int `json:"wrong_post_comment_num"`   // post:comment_num 计数错误的帖子
+	MissingCommentTime    int `json:"missing_comment_time"`     // 不在 comment:time:{postID} 中的顶级评论
+	MissingCommentScore   int `json:"missing_comment_score"`    // 不在 comment:score:{postID} 中的顶级评论
+	MissingCommentNum     int `json:"missing_comment_num"`      // 不在 comment:num 中的顶级评论
+	WrongCommentNum       int `json:"wrong_comment_num"`        // comment:num 计数错误的顶级评论
+	OrphanPosts           int `json:"orphan_posts"`             // redis 中存在但数据库中已不存在的帖子
+	MissingPostTags       int `json:"missing_post_tags"`        // 不在 post:tags:{postID} 中的标签
+	MissingTagTime        int `json:"missing_tag_time"`         // 不在 tag:time:{tag} 中的帖子
+	MissingTagScore       int `json:"missing_tag_score"`        // 不在 tag:score:{tag} 中的帖子
+	OrphanTagPosts        int `json:"orphan_tag_posts"`         // 标签集合中已删除、未发布或已不带该标签的帖子
+
+	Repaired int      `json:"repaired"`          // 已修复的条目数
+	Samples  []string `json:"samples,omitempty"` // 差异示例
+}
+
+// Drift 差异总数
+func (r *DriftReport) Drift() int {
+	return r.MissingPostTime + r.MissingPostScore + r.MissingCommunity +
+		r.MissingPostCommentNum + r.WrongPostCommentNum +
+		r.MissingCommentTime + r.MissingCommentScore + r.MissingCommentNum + r.WrongCommentNum +
+		r.OrphanPosts + r.MissingPostTags + r.MissingTagTime + r.MissingTagScore + r.OrphanTagPosts
+}
+
+func (r *DriftReport) sample(format string, args ...interface{}) {
+	if len(r.Samples) < maxSamples {
+		r.Samples = append(r.Samples, fmt.Sprintf(format, args...))
+	}
+}
+
+var (
+	enabled    = true
+	mode       = ModeSample
+	interval   = defaultInterval
+	sampleSize = defaultSampleSize
+	dryRun     bool
+
+	running    sync.Mutex
+	reportMu   sync.RWMutex
+	lastReport *DriftReport
+)
+
+// Init 读取校验配置，未配置时使用默认值
+func Init(cfg *settings.ReconcileConfig) {
+	if cfg == nil {
+		return
+	}
+	enabled = cfg.Enabled
+	if cfg.Mode != "" {
+		mode = cfg.Mode
+	}
+	if cfg.Interval > 0 {
+		interval = cfg.Interval
+	}
+	if cfg.SampleSize > 0 {
+		sampleSize = cfg.SampleSize
+	}
+	dryRun = cfg.DryRun
+}
+
+// StartReconcileJob 启动定时校验任务
+func StartReconcileJob(ctx context.Context) {
+	if !enabled {
+		return
+	}
+	zap.L().Info("redis 一致性校验任务已启动",
+		zap.String("mode", mode),
+		zap.Duration("interval", interval),
+		zap.Bool("dry_run", dryRun))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			zap.L().Info("redis 一致性校验任务已停止")
+			return
+		case <-ticker.C:
+			if _, err := Run(mode, dryRun); err != nil {
+				zap.L().Error("redis 一致性校验失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+// LastReport 返回最近一次校验的报告，尚未执行过时返回 nil
+func LastReport() *DriftReport {
+	reportMu.RLock()
+	defer reportMu.RUnlock()
+	return lastReport
+}
+
+// Run 立即执行一次校验
+func Run(runMode string, dry bool) (*DriftReport, error) {
+	if !running.TryLock() {
+		return nil, constants.ErrorTaskRunning
+	}
+	defer running.Unlock()
+
+	if runMode != ModeFull {
+		runMode = ModeSample
+	}
+
+	begin := time.Now()
+	report := &DriftReport{
+		Mode:      runMode,
+		DryRun:    dry,
+		StartedAt: begin.Format("2006-01-02 15:04:05"),
+	}
+
+	// 1. 从数据库出发，检查 redis 中缺失的成员和错误的计数
+	if runMode == ModeFull {
+		var lastID int64
+		for {
+			posts, err := dao.GetPostBatch(lastID, batchSize)
+			if err != nil {
+				return nil, err
+			}
+			if len(posts) == 0 {
+				break
+			}
+			if err := checkPosts(posts, report); err != nil {
+				return nil, err
+			}
+			lastID = posts[len(posts)-1].ID
+		}
+	} else {
+		posts, err := dao.GetRecentPosts(sampleSize)
+		if err != nil {
+			return nil, err
+		}
+		for start := 0; start < len(posts); start += batchSize {
+			end := min(start+batchSize, len(posts))
+			if err := checkPosts(posts[start:end], report); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 2. 从 redis 出发，检查数据库中已不存在的帖子，以及标签集合中不应存在的帖子
+	if err := checkOrphans(runMode, report); err != nil {
+		return nil, err
+	}
+	if err := checkTagOrphans(runMode, report); err != nil {
+		return nil, err
+	}
+
+	report.Duration = time.Since(begin).String()
+	if report.Drift() > 0 {
+		zap.L().Warn("redis 与数据库存在差异", zap.Any("report", report))
+	} else {
+		zap.L().Info("redis 一致性校验完成，无差异", zap.Int("posts_checked", report.PostsChecked))
+	}
+
+	reportMu.Lock()
+	lastReport = report
+	reportMu.Unlock()
+	return report, nil
+}
+
+// 检查一批帖子及其评论在 redis 中的状态
+func checkPosts(posts []*entity.Post, report *DriftReport) error {
+	refs := make([]redis.PostRef, len(posts))
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		refs[i] = redis.PostRef{ID: post.ID, CommunityID: post.CommunityID}
+		postIDs[i] = post.ID
+	}
+
+	states, err := redis.GetPostStates(refs)
+	if err != nil {
+		return err
+	}
+	comments, err := dao.GetCommentsByPostIDs(postIDs)
+	if err != nil {
+		return err
+	}
+	tagNames, err := dao.GetPostTagNames(postIDs)
+	if err != nil {
+		return err
+	}
+	report.PostsChecked += len(posts)
+	report.CommentsChecked += len(comments)
+
+	commentsByPost := make(map[int64][]*entity.Comment)
+	for _, comment := range comments {
+		commentsByPost[comment.PostID] = append(commentsByPost[comment.PostID], comment)
+	}
+
+	postTime := make(map[string]float64)
+	postScore := make(map[string]float64)
+	postCommentNum := make(map[string]redis.CountRepair)
+	communityPosts := make(map[int64][]string)
+	var rescore []*entity.Post
+
+	for i, post := range posts {
+		postIDStr := strconv.FormatInt(post.ID, 10)
+		state := states[i]
+
+		if !state.InTime {
+			report.MissingPostTime++
+			report.sample("post:time 缺少帖子 %d", post.ID)
+			postTime[postIDStr] = float64(post.CreatedAt.Unix())
+		}
+		if !state.InScore {
+			report.MissingPostScore++
+			report.sample("post:score 缺少帖子 %d", post.ID)
+			postScore[postIDStr] = float64(post.CreatedAt.Unix())
+			rescore = append(rescore, post)
+		}
+		if !state.InCommunity {
+			report.MissingCommunity++
+			report.sample("community:%d 缺少帖子 %d", post.CommunityID, post.ID)
+			communityPosts[post.CommunityID] = append(communityPosts[post.CommunityID], postIDStr)
+		}
+
+		commentNum := liveCommentNum(commentsByPost[post.ID])
+		if !state.HasCount {
+			report.MissingPostCommentNum++
+			report.sample("post:comment_num 缺少帖子 %d", post.ID)
+			postCommentNum[postIDStr] = redis.CountRepair{Expected: commentNum}
+		} else if state.CommentNum != commentNum {
+			report.WrongPostCommentNum++
+			report.sample("post:comment_num 帖子 %d 计数为 %v，实际为 %v", post.ID, state.CommentNum, commentNum)
+			postCommentNum[postIDStr] = redis.CountRepair{HasObserved: true, Observed: state.CommentNum, Expected: commentNum}
+		}
+
+		if err := checkComments(post, commentsByPost[post.ID], report); err != nil {
+			return err
+		}
+		if err := checkTags(post, tagNames[post.ID], state, report); err != nil {
+			return err
+		}
+	}
+
+	if report.DryRun {
+		return nil
+	}
+
+	// 修复
+	if err := redis.ZAddMembers(redis.KeyPostTimeZSet, postTime); err != nil {
+		return err
+	}
+	if err := redis.ZAddMembers(redis.KeyPostScoreZSet, postScore); err != nil {
+		return err
+	}
+	// 评论数只在校验期间没有变化时修复
+	repairedCommentNum, err := redis.RepairCounts(redis.KeyPostCommentNumZSet, postCommentNum)
+	if err != nil {
+		return err
+	}
+	repaired := len(postTime) + len(postScore) + repairedCommentNum
+	for communityID, ids := range communityPosts {
+		if err := redis.SAddMembers(redis.KeyCommunitySetPF+strconv.FormatInt(communityID, 10), ids...); err != nil {
+			return err
+		}
+		repaired += len(ids)
+	}
+	// 补齐的分数按当前排序算法重算（legacy 算法下保持发布时间戳）
+	for _, post := range rescore {
+		if err := ranking.RefreshPost(post); err != nil {
+			return err
+		}
+	}
+	report.Repaired += repaired
+	return nil
+}
+
+// 统计未删除的评论数，墓碑评论保留在评论列表中但不计入评论数
+func liveCommentNum(comments []*entity.Comment) float64 {
+	var num float64
+	for _, comment := range comments {
+		if comment.TombstonedAt == nil {
+			num++
+		}
+	}
+	return num
+}
+
+// 检查单个帖子的顶级评论在 redis 中的状态
+func checkComments(post *entity.Post, comments []*entity.Comment, report *DriftReport) error {
+	var tops []*entity.Comment
+	replyNum := make(map[int64]float64)
+	for _, comment := range comments {
+		if comment.RootID == nil {
+			tops = append(tops, comment)
+			continue
+		}
+		if comment.TombstonedAt == nil { // 墓碑评论不计入回复数
+			replyNum[*comment.RootID]++
+		}
+	}
+	if len(tops) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tops))
+	for i, comment := range tops {
+		ids[i] = strconv.FormatInt(comment.ID, 10)
+	}
+	states, err := redis.GetTopCommentStates(post.ID, ids)
+	if err != nil {
+		return err
+	}
+
+	commentTime := make(map[string]float64)
+	commentScore := make(map[string]float64)
+	commentNum := make(map[string]redis.CountRepair)
+	for i, comment := range tops {
+		state := states[i]
+		createdAt := float64(comment.CreatedAt.Unix())
+
+		if !state.InTime {
+			report.MissingCommentTime++
+			report.sample("comment:time:%d 缺少评论 %d", post.ID, comment.ID)
+			commentTime[ids[i]] = createdAt
+		}
+		if !state.InScore {
+			report.MissingCommentScore++
+			report.sample("comment:score:%d 缺少评论 %d", post.ID, comment.ID)
+			commentScore[ids[i]] = createdAt
+		}
+
+		expected := replyNum[comment.ID]
+		if !state.HasCount {
+			report.MissingCommentNum++
+			report.sample("comment:num 缺少评论 %d", comment.ID)
+			commentNum[ids[i]] = redis.CountRepair{Expected: expected}
+		} else if state.ReplyNum != expected {
+			report.WrongCommentNum++
+			report.sample("comment:num 评论 %d 计数为 %v，实际为 %v", comment.ID, state.ReplyNum, expected)
+			commentNum[ids[i]] = redis.CountRepair{HasObserved: true, Observed: state.ReplyNum, Expected: expected}
+		}
+	}
+
+	if report.DryRun {
+		return nil
+	}
+
+	postIDStr := strconv.FormatInt(post.ID, 10)
+	if err := redis.ZAddMembers(redis.KeyCommentTimeZSetPF+postIDStr, commentTime); err != nil {
+		return err
+	}
+	if err := redis.ZAddMembers(redis.KeyCommentScoreZSetPF+postIDStr, commentScore); err != nil {
+		return err
+	}
+	// 回复数只在校验期间没有变化时修复
+	repairedCommentNum, err := redis.RepairCounts(redis.KeyCommentNumZSet, commentNum)
+	if err != nil {
+		return err
+	}
+	report.Repaired += len(commentTime) + len(commentScore) + repairedCommentNum
+	return nil
+}
+
+// 检查单个帖子的标签在 redis 中的状态，缺失的热度以 post:score 中的分数补齐
+func checkTags(post *entity.Post, tags []string, state redis.PostState, report *DriftReport) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	states, err := redis.GetPostTagStates(post.ID, tags)
+	if err != nil {
+		return err
+	}
+
+	postIDStr := strconv.FormatInt(post.ID, 10)
+	createdAt := float64(post.CreatedAt.Unix())
+	score := createdAt
+	if state.InScore {
+		score = state.Score
+	}
+
+	var postTags, tagTime, tagScore []string
+	for i, tag := range tags {
+		if !states[i].InPostTags {
+			report.MissingPostTags++
+			report.sample("post:tags:%d 缺少标签 %s", post.ID, tag)
+			postTags = append(postTags, tag)
+		}
+		if !states[i].InTime {
+			report.MissingTagTime++
+			report.sample("tag:time:%s 缺少帖子 %d", tag, post.ID)
+			tagTime = append(tagTime, tag)
+		}
+		if !states[i].InScore {
+			report.MissingTagScore++
+			report.sample("tag:score:%s 缺少帖子 %d", tag, post.ID)
+			tagScore = append(tagScore, tag)
+		}
+	}
+
+	if report.DryRun {
+		return nil
+	}
+
+	if err := redis.SAddMembers(redis.KeyPostTagsSetPF+postIDStr, postTags...); err != nil {
+		return err
+	}
+	for _, tag := range tagTime {
+		if err := redis.ZAddMembers(redis.KeyTagTimeZSetPF+tag, map[string]float64{postIDStr: createdAt}); err != nil {
+			return err
+		}
+	}
+	for _, tag := range tagScore {
+		if err := redis.ZAddMembers(redis.KeyTagScoreZSetPF+tag, map[string]float64{postIDStr: score}); err != nil {
+			return err
+		}
+	}
+	report.Repaired += len(postTags) + len(tagTime) + len(tagScore)
+	return nil
+}
+
+// 检查 post:time 中数据库已不存在（或已删除）的帖子
+func checkOrphans(runMode string, report *DriftReport) error {
+	var offset int64
+	for {
+		count := int64(batchSize)
+		if runMode == ModeSample {
+			count = int64(min(batchSize, sampleSize-report.RedisMembersChecked))
+			if count <= 0 {
+				return nil
+			}
+		}
+
+		ids, err := redis.GetRecentPostIDs(offset, count)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		report.RedisMembersChecked += len(ids)
+
+		postIDs := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if postID, err := strconv.ParseInt(id, 10, 64); err == nil {
+				postIDs = append(postIDs, postID)
+			}
+		}
+		posts, err := dao.GetPostsByIDsUnscoped(postIDs)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]*entity.Post, len(posts))
+		for _, post := range posts {
+			found[strconv.FormatInt(post.ID, 10)] = post
+		}
+
+		removed := 0
+		for _, id := range ids {
+			// 已删除或尚未发布的帖子都不应该出现在 redis 中
+			post, ok := found[id]
+			if ok && !post.DeletedAt.Valid && post.Status == entity.PostStatusPublished {
+				continue
+			}
+			report.OrphanPosts++
+			report.sample("post:time 中的帖子 %s 在数据库中不存在", id)
+			if report.DryRun {
+				continue
+			}
+
+			// 数据库中能查到的帖子可以得到社区，按正常流程删除；否则只清理全局集合
+			if ok {
+				err = redis.DeletePost(post.ID, post.CommunityID)
+			} else {
+				err = redis.RemoveOrphanPosts(id)
+			}
+			if err != nil {
+				return err
+			}
+			removed++
+			report.Repaired++
+		}
+
+		// 删除的成员会使后续成员的排名前移
+		offset += int64(len(ids) - removed)
+	}
+}
+
+// 检查标签的时间和热度集合中已删除、未发布或已不带该标签的帖子
+// 抽样校验时每个集合只检查最新的 sampleSize 个成员
+func checkTagOrphans(runMode string, report *DriftReport) error {
+	timeTags, err := redis.ScanKeySuffixes(redis.KeyTagTimeZSetPF)
+	if err != nil {
+		return err
+	}
+	scoreTags, err := redis.ScanKeySuffixes(redis.KeyTagScoreZSetPF)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range timeTags {
+		if err := checkTagKeyOrphans(runMode, redis.KeyTagTimeZSetPF, tag, report); err != nil {
+			return err
+		}
+	}
+	for _, tag := range scoreTags {
+		if err := checkTagKeyOrphans(runMode, redis.KeyTagScoreZSetPF, tag, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 检查单个标签集合（tag:time:{tag} 或 tag:score:{tag}）中的帖子
+func checkTagKeyOrphans(runMode, prefix, tag string, report *DriftReport) error {
+	var offset, checked int64
+	for {
+		count := int64(batchSize)
+		if runMode == ModeSample {
+			count = min(int64(batchSize), int64(sampleSize)-checked)
+			if count <= 0 {
+				return nil
+			}
+		}
+
+		ids, err := redis.GetTagPostIDs(prefix+tag, offset, count)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		checked += int64(len(ids))
+
+		postIDs := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if postID, err := strconv.ParseInt(id, 10, 64); err == nil {
+				postIDs = append(postIDs, postID)
+			}
+		}
+		posts, err := dao.GetPostsByIDsUnscoped(postIDs)
+		if err != nil {
+			return err
+		}
+		tagNames, err := dao.GetPostTagNames(postIDs)
+		if err != nil {
+			return err
+		}
+		valid := make(map[string]bool, len(posts))
+		for _, post := range posts {
+			if post.DeletedAt.Valid || post.Status != entity.PostStatusPublished {
+				continue
+			}
+			valid[strconv.FormatInt(post.ID, 10)] = slices.Contains(tagNames[post.ID], tag)
+		}
+
+		var orphans []string
+		for _, id := range ids {
+			if valid[id] {
+				continue
+			}
+			report.OrphanTagPosts++
+			report.sample("%s%s 中的帖子 %s 已删除、未发布或不再带有该标签", prefix, tag, id)
+			orphans = append(orphans, id)
+		}
+
+		removed := 0
+		if !report.DryRun && len(orphans) > 0 {
+			if err := redis.RemoveTagPosts(tag, orphans...); err != nil {
+				return err
+			}
+			removed = len(orphans)
+			report.Repaired += removed
+		}
+
+		// 删除的成员会使后续成员的排名前移
+		offset += int64(len(ids) - removed)
+	}
+}
@@ -26,6 +26,10 @@ type AppConfig struct {
 	*JWTConfig        `mapstructure:"jwt"`   // 新增JWT配置
 	*KafkaConfig      `mapstructure:"kafka"` // 新增 Kafka 配置
 	*PulsarConfig     `mapstructure:"pulsar"`
+	*RocketMQConfig   `mapstructure:"rocketmq"`
+	*BrokerConfig     `mapstructure:"broker"`    // 消息队列后端和异步发帖配置
+	*RankingConfig    `mapstructure:"ranking"`   // 帖子热度排序配置
+	*ReconcileConfig  `mapstructure:"reconcile"` // redis 与数据库一致性校验配置
 }
 
 // PostgreSQLConfig 定义了 PostgreSQL 数据库的配置
@@ -100,6 +104,63 @@ type KafkaConfig struct {
 	RetryMax     int           `mapstructure:"retry_max"`     // 最大重试次数
 	WriteTimeout time.Duration `mapstructure:"write_timeout"` // 写入超时时间
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`  // 读取超时时间
+	// 以下配置已移到 broker 中，为兼容旧配置保留：broker 中未配置时使用这里的值
+	AsyncPostCreation bool          `mapstructure:"async_post_creation"` // 异步发帖
+	TopicDeadLetter   string        `mapstructure:"topic_dead_letter"`   // 死信主题
+	MaxAttempts       int           `mapstructure:"max_attempts"`        // 消费最大尝试次数
+	RetryBackoff      time.Duration `mapstructure:"retry_backoff"`       // 首次重试间隔
+}
+
+// BrokerConfig 定义了消息队列后端和异步发帖消息管道的配置
+type BrokerConfig struct {
+	// 消息队列后端：kafka / pulsar / rocketmq / memory（进程内通道，用于本地开发和测试）
+	// 未配置时启用了 kafka 则使用 kafka，否则不启用消息队列
+	Backend string `mapstructure:"backend"`
+	// 异步发帖：开启后 POST /post 只发送消息，由消费者写入帖子
+	AsyncPostCreation bool `mapstructure:"async_post_creation"`
+	// 消费失败处理：重试 MaxAttempts 次（默认 5 次，间隔从 RetryBackoff 开始翻倍，默认 1 秒）后写入死信主题
+	TopicDeadLetter string        `mapstructure:"topic_dead_letter"` // 死信主题，默认为 {发帖主题}.dlq
+	MaxAttempts     int           `mapstructure:"max_attempts"`      // 消费最大尝试次数
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff"`     // 首次重试间隔
+	// 事务发件箱转发任务
+	OutboxInterval    time.Duration `mapstructure:"outbox_interval"`     // 转发间隔，默认 1 秒
+	OutboxBatchSize   int           `mapstructure:"outbox_batch_size"`   // 每次转发的最大消息数，默认 100
+	OutboxMaxAttempts int           `mapstructure:"outbox_max_attempts"` // 单条消息的最大发送次数，达到后搁置，默认 10
+}
+
+// 当前使用的消息队列后端，未启用时返回空字符串
+func (c *AppConfig) MessageBackend() string {
+	if c.BrokerConfig != nil && c.BrokerConfig.Backend != "" {
+		return c.BrokerConfig.Backend
+	}
+	if c.KafkaConfig != nil && c.KafkaConfig.Enabled {
+		return "kafka"
+	}
+	return ""
+}
+
+// 是否启用异步发帖（需要同时启用消息队列），兼容 kafka.async_post_creation
+func (c *AppConfig) AsyncPostCreationEnabled() bool {
+	enabled := (c.BrokerConfig != nil && c.BrokerConfig.AsyncPostCreation) ||
+		(c.KafkaConfig != nil && c.KafkaConfig.AsyncPostCreation)
+	return enabled && c.MessageBackend() != ""
+}
+
+// RankingConfig 定义了帖子热度排序算法及重算任务的配置
+type RankingConfig struct {
+	Algorithm         string        `mapstructure:"algorithm"`          // 排序算法：legacy（默认，按票数累加）/ reddit / hackernews
+	Gravity           float64       `mapstructure:"gravity"`            // hackernews 算法的重力系数，默认 1.8
+	Window            time.Duration `mapstructure:"window"`             // 热度排序的帖子时间窗口，默认一周，超出窗口的帖子使用保底分数
+	RecomputeInterval time.Duration `mapstructure:"recompute_interval"` // 定时重算间隔，默认 10 分钟
+}
+
+// ReconcileConfig 定义了 redis 与数据库定时一致性校验的配置，未配置时以默认值启用
+type ReconcileConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`     // 是否启用定时校验
+	Mode       string        `mapstructure:"mode"`        // 校验方式：sample（默认，只校验最新的帖子）/ full（全量）
+	Interval   time.Duration `mapstructure:"interval"`    // 校验间隔，默认 30 分钟
+	SampleSize int           `mapstructure:"sample_size"` // sample 模式下校验的帖子数量，默认 1000
+	DryRun     bool          `mapstructure:"dry_run"`     // 只报告差异，不修复
 }
 
 type LogConfig struct {
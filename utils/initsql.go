@@ -19,6 +19,20 @@ func InitSqlTable() (err error) {
 		&entity.Comment{},
 		&entity.LoginHistory{},
 		&entity.PostVote{},
+		&entity.PostRevision{},
+		&entity.BookmarkFolder{},
+		&entity.PostBookmark{},
+		&entity.Tag{},
+		&entity.PostTag{},
+		&entity.PostAttachment{},
+		&entity.PostPin{},
+		&entity.CommunityModerator{},
+		&entity.CommentRevision{},
+		&entity.ModerationLog{},
+		&entity.Mention{},
+		&entity.Notification{},
+		&entity.ProcessedMessage{},
+		&entity.OutboxMessage{},
 	)
 	return
 }